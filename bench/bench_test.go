@@ -6,7 +6,13 @@ import (
 	"github.com/hashicorp/golang-lru/arc/v2"
 	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/tysonmote/cache/cachetest"
+	"github.com/tysonmote/cache/clock"
+	"github.com/tysonmote/cache/clockpro"
+	"github.com/tysonmote/cache/exactlfu"
 	"github.com/tysonmote/cache/lfu"
+	"github.com/tysonmote/cache/s3fifo"
+	"github.com/tysonmote/cache/sieve"
+	"github.com/tysonmote/cache/wtinylfu"
 )
 
 func BenchmarkTysonmoteLFU(b *testing.B) {
@@ -33,6 +39,108 @@ func BenchmarkTysonmoteLFUSharded256(b *testing.B) {
 	})
 }
 
+// BenchmarkTysonmoteLFUSharded64Strict is BenchmarkTysonmoteLFUSharded64 with
+// a hard global capacity instead of per-shard slack.
+func BenchmarkTysonmoteLFUSharded64Strict(b *testing.B) {
+	cachetest.BenchmarkCache(b, func(size int) cachetest.Cache[int, int] {
+		return lfu.NewWithOptions[int, int](size, lfu.WithShards(64), lfu.WithStrictCapacity())
+	})
+}
+
+// BenchmarkTysonmoteLFUBatch compares looking up 100 keys one at a time with
+// looking them up in one GetMany call.
+func BenchmarkTysonmoteLFUBatch(b *testing.B) {
+	const size = 100_000
+	c := lfu.NewSharded[int, int](size, 64)
+	keys := make([]int, size)
+	for i := range keys {
+		keys[i] = i
+		c.Set(i, i)
+	}
+	b.Run("get", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				for _, k := range keys[i : i+100] {
+					c.Get(k)
+				}
+				i = (i + 100) % size
+			}
+		})
+	})
+	b.Run("get_many", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				c.GetMany(keys[i : i+100])
+				i = (i + 100) % size
+			}
+		})
+	})
+}
+
+// BenchmarkExactLFU and BenchmarkExactLFUSharded64 measure the O(1) exact LFU
+// that lfu's probabilistic buckets approximate.
+func BenchmarkExactLFU(b *testing.B) {
+	cachetest.BenchmarkCache(b, func(size int) cachetest.Cache[int, int] {
+		return exactlfu.New[int, int](size)
+	})
+}
+
+func BenchmarkExactLFUSharded64(b *testing.B) {
+	cachetest.BenchmarkCache(b, func(size int) cachetest.Cache[int, int] {
+		return exactlfu.NewSharded[int, int](size, 64)
+	})
+}
+
+func BenchmarkS3FIFO(b *testing.B) {
+	cachetest.BenchmarkCache(b, func(size int) cachetest.Cache[int, int] {
+		return s3fifo.New[int, int](size)
+	})
+}
+
+func BenchmarkSIEVE(b *testing.B) {
+	cachetest.BenchmarkCache(b, func(size int) cachetest.Cache[int, int] {
+		return sieve.New[int, int](size)
+	})
+}
+
+func BenchmarkSIEVESharded16(b *testing.B) {
+	cachetest.BenchmarkCache(b, func(size int) cachetest.Cache[int, int] {
+		return sieve.NewSharded[int, int](size, 16)
+	})
+}
+
+func BenchmarkSIEVESharded64(b *testing.B) {
+	cachetest.BenchmarkCache(b, func(size int) cachetest.Cache[int, int] {
+		return sieve.NewSharded[int, int](size, 64)
+	})
+}
+
+func BenchmarkWTinyLFU(b *testing.B) {
+	cachetest.BenchmarkCache(b, func(size int) cachetest.Cache[int, int] {
+		return wtinylfu.New[int, int](size)
+	})
+}
+
+func BenchmarkWTinyLFUHillClimbing(b *testing.B) {
+	cachetest.BenchmarkCache(b, func(size int) cachetest.Cache[int, int] {
+		return wtinylfu.New[int, int](size, wtinylfu.WithHillClimbing())
+	})
+}
+
+func BenchmarkClock(b *testing.B) {
+	cachetest.BenchmarkCache(b, func(size int) cachetest.Cache[int, int] {
+		return clock.New[int, int](size)
+	})
+}
+
+func BenchmarkClockPro(b *testing.B) {
+	cachetest.BenchmarkCache(b, func(size int) cachetest.Cache[int, int] {
+		return clockpro.New[int, int](size)
+	})
+}
+
 // External cache implementations
 
 type hashiLRU[K comparable, V any] struct {
@@ -0,0 +1,146 @@
+package bench
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/golang-lru/arc/v2"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/tysonmote/cache/cachetest"
+	"github.com/tysonmote/cache/clock"
+	"github.com/tysonmote/cache/clockpro"
+	"github.com/tysonmote/cache/exactlfu"
+	"github.com/tysonmote/cache/lfu"
+	"github.com/tysonmote/cache/s3fifo"
+	"github.com/tysonmote/cache/sieve"
+	"github.com/tysonmote/cache/trace"
+	"github.com/tysonmote/cache/wtinylfu"
+)
+
+// hitRatioTraces are the trace files in ../trace with the cache size each is
+// replayed at.
+var hitRatioTraces = []struct {
+	file string
+	size int
+}{
+	{"ds1.arc.gz", 1_000_000},
+	{"oltp.arc.gz", 1_000},
+	{"p3.arc.gz", 100_000},
+	{"p8.arc.gz", 100_000},
+	{"s3.arc.gz", 400_000},
+	{"loop.lirs.gz", 1_000},
+}
+
+// hitRatioCaches are the caches compared by BenchmarkHitRatio and
+// BenchmarkMemoryPerEntry.
+var hitRatioCaches = []struct {
+	name   string
+	create func(size int) cachetest.Cache[int, int]
+}{
+	{"lfu", func(size int) cachetest.Cache[int, int] {
+		return lfu.New[int, int](size)
+	}},
+	{"lfu-aging", func(size int) cachetest.Cache[int, int] {
+		return lfu.NewWithOptions[int, int](size, lfu.WithAging(4*size))
+	}},
+	{"lfu-tinylfu", func(size int) cachetest.Cache[int, int] {
+		return lfu.NewWithOptions[int, int](size, lfu.WithAdmission())
+	}},
+	{"lfu-sampled", func(size int) cachetest.Cache[int, int] {
+		return lfu.NewWithOptions[int, int](size, lfu.WithVictimSample(8))
+	}},
+	{"exactlfu", func(size int) cachetest.Cache[int, int] {
+		return exactlfu.New[int, int](size)
+	}},
+	{"s3fifo", func(size int) cachetest.Cache[int, int] {
+		return s3fifo.New[int, int](size)
+	}},
+	{"sieve", func(size int) cachetest.Cache[int, int] {
+		return sieve.New[int, int](size)
+	}},
+	{"wtinylfu", func(size int) cachetest.Cache[int, int] {
+		return wtinylfu.New[int, int](size)
+	}},
+	{"wtinylfu-climbing", func(size int) cachetest.Cache[int, int] {
+		return wtinylfu.New[int, int](size, wtinylfu.WithHillClimbing())
+	}},
+	{"clock", func(size int) cachetest.Cache[int, int] {
+		return clock.New[int, int](size)
+	}},
+	{"clockpro", func(size int) cachetest.Cache[int, int] {
+		return clockpro.New[int, int](size)
+	}},
+	{"hashicorp-lru", func(size int) cachetest.Cache[int, int] {
+		c, err := lru.New[int, int](size)
+		if err != nil {
+			panic(err)
+		}
+		return &hashiLRU[int, int]{c}
+	}},
+	{"hashicorp-arc", func(size int) cachetest.Cache[int, int] {
+		c, err := arc.NewARC[int, int](size)
+		if err != nil {
+			panic(err)
+		}
+		return &hashiARC[int, int]{c}
+	}},
+}
+
+// BenchmarkHitRatio replays each trace through each cache, counting a Get that
+// misses followed by a Set as one miss, and reports the hit ratio as hit%. The
+// traces are stored with Git LFS; run `git lfs pull` first, or traces that are
+// still LFS pointers are skipped.
+//
+//	go test ./bench -run '^$' -bench HitRatio -benchtime 1x
+func BenchmarkHitRatio(b *testing.B) {
+	for _, tr := range hitRatioTraces {
+		path := filepath.Join("..", "trace", tr.file)
+		for _, c := range hitRatioCaches {
+			b.Run(tr.file+"/"+c.name, func(b *testing.B) {
+				var ratio float64
+				for i := 0; i < b.N; i++ {
+					var err error
+					ratio, err = replay(path, c.create(tr.size))
+					if err != nil {
+						b.Skipf("%s: %v", path, err)
+					}
+				}
+				b.ReportMetric(100*ratio, "hit%")
+			})
+		}
+	}
+}
+
+func replay(path string, c cachetest.Cache[int, int]) (float64, error) {
+	t, err := trace.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer t.Close()
+
+	keys := make([]int, 4096)
+	var hits, total int
+	for {
+		n, err := t.Read(keys)
+		for _, k := range keys[:n] {
+			if _, ok := c.Get(k); ok {
+				hits++
+			} else {
+				c.Set(k, k)
+			}
+		}
+		total += n
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if total == 0 {
+		return 0, nil
+	}
+	return float64(hits) / float64(total), nil
+}
@@ -0,0 +1,44 @@
+package bench
+
+import (
+	"runtime"
+	"testing"
+)
+
+// memoryEntries is the number of entries BenchmarkMemoryPerEntry adds to each
+// cache, matching the cache size of cachetest.BenchmarkCache.
+const memoryEntries = 100_000
+
+// BenchmarkMemoryPerEntry fills each cache with memoryEntries int keys and
+// values and reports the heap it retains per entry as B/entry, including
+// fixed structures such as sketches and ghost queues. An int key and value
+// take 16 bytes, so anything above that is the policy's overhead: map
+// buckets, list pointers, reference bits, and frequency metadata. -benchmem
+// reports the allocations of filling the cache, growth included.
+//
+//	go test ./bench -run '^$' -bench MemoryPerEntry -benchmem
+func BenchmarkMemoryPerEntry(b *testing.B) {
+	for _, c := range hitRatioCaches {
+		b.Run(c.name, func(b *testing.B) {
+			var perEntry float64
+			for i := 0; i < b.N; i++ {
+				before := heapAlloc()
+				cache := c.create(memoryEntries)
+				for k := 0; k < memoryEntries; k++ {
+					cache.Set(k, k)
+				}
+				perEntry = float64(heapAlloc()-before) / memoryEntries
+				runtime.KeepAlive(cache)
+			}
+			b.ReportMetric(perEntry, "B/entry")
+		})
+	}
+}
+
+// heapAlloc returns the bytes of live heap objects after a collection.
+func heapAlloc() int64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return int64(m.HeapAlloc)
+}
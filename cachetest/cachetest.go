@@ -3,6 +3,7 @@ package cachetest
 import (
 	"fmt"
 	"math/rand"
+	"sync"
 	"testing"
 	"time"
 )
@@ -109,6 +110,170 @@ func testCacheEvictionPolicy(c Cache[int, int]) error {
 	return nil
 }
 
+// TestRandomOps checks caches of every size from 0 to maxSize, made by
+// create, against a model of what they may hold. For each size it runs a
+// random sequence of Get, Peek, Set, Remove, and Clear calls over twice as
+// many keys as fit, and checks that a hit returns the value most recently set
+// for the key, that a removed or cleared key misses, that a cache of size 0
+// never hits, that a key is present right after it is set, and, if the cache
+// has a Len method, that it never holds more than size entries. It then calls
+// Get, Set, and Remove on a cache of maxSize from several goroutines and
+// checks the values of hits and the final Len. Each of checks is called with
+// every cache once its calls are done, to verify the cache's internal
+// invariants.
+func TestRandomOps[C Cache[int, int]](create func(size int) C, maxSize int, checks ...func(c C) error) error {
+	run := func(size int, test func(Cache[int, int], int) error) error {
+		c := create(size)
+		err := test(c, size)
+		for _, check := range checks {
+			if err != nil {
+				break
+			}
+			err = check(c)
+		}
+		if err != nil {
+			return fmt.Errorf("size %d: %w", size, err)
+		}
+		return nil
+	}
+	for size := 0; size <= maxSize; size++ {
+		if err := run(size, testRandomOps); err != nil {
+			return err
+		}
+	}
+	return run(maxSize, testConcurrentOps)
+}
+
+func testRandomOps(c Cache[int, int], size int) error {
+	rng := rand.New(rand.NewSource(int64(size)))
+	keys := 2*size + 2
+	// set holds the value most recently set for each key that has not been
+	// removed or cleared since; a cache may have evicted any of them.
+	set := map[int]int{}
+	hit := func(op string, k, v int, ok bool) error {
+		if !ok {
+			return nil
+		}
+		if size == 0 {
+			return fmt.Errorf("%s(%d) hit in a cache of size 0", op, k)
+		}
+		if want, ok := set[k]; !ok || v != want {
+			return fmt.Errorf("%s(%d) = %d, want a miss or the last value set, %d (set %v)", op, k, v, want, ok)
+		}
+		return nil
+	}
+	for i := 0; i < 20*keys; i++ {
+		k := rng.Intn(keys)
+		switch op := rng.Intn(100); {
+		case op < 40:
+			v, ok := c.Get(k)
+			if err := hit("Get", k, v, ok); err != nil {
+				return err
+			}
+		case op < 50:
+			v, ok := c.Peek(k)
+			if err := hit("Peek", k, v, ok); err != nil {
+				return err
+			}
+		case op < 90:
+			c.Set(k, i)
+			set[k] = i
+			if v, ok := c.Peek(k); size > 0 && (!ok || v != i) {
+				return fmt.Errorf("Peek(%d) = %d, %v right after Set(%d, %d)", k, v, ok, k, i)
+			}
+		case op < 99:
+			if _, ok := set[k]; c.Remove(k) && !ok {
+				return fmt.Errorf("Remove(%d) reported a key that was not set", k)
+			}
+			delete(set, k)
+			if _, ok := c.Peek(k); ok {
+				return fmt.Errorf("Peek(%d) hit after Remove", k)
+			}
+		default:
+			c.Clear()
+			set = map[int]int{}
+		}
+		if l, ok := c.(interface{ Len() int }); ok && l.Len() > size {
+			return fmt.Errorf("Len() = %d, over the size", l.Len())
+		}
+	}
+	return nil
+}
+
+func testConcurrentOps(c Cache[int, int], size int) error {
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(g)))
+			for i := 0; i < 10_000; i++ {
+				k := rng.Intn(4*size + 4)
+				switch rng.Intn(10) {
+				case 0:
+					c.Remove(k)
+				case 1, 2, 3:
+					c.Set(k, k)
+				default:
+					if v, ok := c.Get(k); ok && v != k {
+						errs <- fmt.Errorf("Get(%d) = %d, want %d", k, v, k)
+						return
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return err
+	}
+	if l, ok := c.(interface{ Len() int }); ok && l.Len() > size {
+		return fmt.Errorf("Len() = %d after concurrent calls, over the size", l.Len())
+	}
+	return nil
+}
+
+// RefreshHitRatio replays a refresh-heavy workload against c, a cache of the
+// given size, and returns the hit ratio of reads of hot keys. Half of the
+// cache's capacity is taken by hot keys that are read often and also rewritten
+// with Set every few operations, as a service refreshing cached values would;
+// the reads are interleaved with one-off reads of cold keys, which are added
+// on a miss. A cache that treats a rewrite as a new, cold entry tends to evict
+// freshly rewritten hot keys, so its ratio is lower than one that keeps their
+// frequency.
+func RefreshHitRatio(c Cache[int, int], size int) float64 {
+	hot := size / 2
+	if hot < 1 {
+		hot = 1
+	}
+	rng := rand.New(rand.NewSource(1))
+	cold := 0
+	var hits, reads int
+	for i := 0; i < 100*size; i++ {
+		if i%4 == 0 {
+			k := rng.Intn(hot)
+			c.Set(k, i)
+		}
+		if rng.Intn(2) == 0 {
+			cold--
+			if _, ok := c.Get(cold); !ok {
+				c.Set(cold, i)
+			}
+			continue
+		}
+		k := rng.Intn(hot)
+		reads++
+		if _, ok := c.Get(k); ok {
+			hits++
+		} else {
+			c.Set(k, i)
+		}
+	}
+	return float64(hits) / float64(reads)
+}
+
 func BenchmarkCache(b *testing.B, create func(size int) Cache[int, int]) {
 	size := 100_000
 
@@ -0,0 +1,183 @@
+package clock
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Cache is a thread-safe, fixed-size, in-memory cache with the CLOCK
+// (second-chance) eviction policy.
+//
+// Entries are stored by value in a slice used as a circular buffer, and a map
+// indexes them by slot, so there are no per-entry allocations or list
+// pointers. Each entry has a reference bit that Get sets. To evict, a hand
+// sweeps the buffer, clearing reference bits, and replaces the first entry
+// whose bit is already clear with the new one; the hand stays after it for the
+// next eviction. A hit never moves an entry, so Get takes only a read lock and
+// concurrent hits do not contend.
+type Cache[K comparable, V any] struct {
+	mu    sync.RWMutex
+	index map[K]int32
+	slots []slot[K, V]
+
+	// free holds the indices of slots emptied by Remove, for reuse before the
+	// buffer grows.
+	free []int32
+	hand int32
+	size int
+}
+
+type slot[K comparable, V any] struct {
+	key   K
+	value V
+	ref   atomic.Bool
+}
+
+// New returns a new Cache ready for use with a maximum capacity of size
+// items. A size of 0 disables caching: Set does not retain entries and Get
+// always misses. New panics if size does not fit in an int32.
+func New[K comparable, V any](size int) *Cache[K, V] {
+	if size < 0 {
+		panic("clock: size must not be negative")
+	}
+	if size > math.MaxInt32 {
+		panic("clock: size must be at most math.MaxInt32")
+	}
+	return &Cache[K, V]{
+		index: map[K]int32{},
+		size:  size,
+	}
+}
+
+// Get returns a value from the cache if it exists. If the value does not
+// exist, ok is false.
+func (c *Cache[K, V]) Get(key K) (v V, ok bool) {
+	c.mu.RLock()
+	i, ok := c.index[key]
+	if ok {
+		s := &c.slots[i]
+		if !s.ref.Load() {
+			s.ref.Store(true)
+		}
+		v = s.value
+	}
+	c.mu.RUnlock()
+	return v, ok
+}
+
+// Peek returns a value from the cache if it exists, without setting its
+// reference bit. If the value does not exist, ok is false.
+func (c *Cache[K, V]) Peek(key K) (v V, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if i, ok := c.index[key]; ok {
+		return c.slots[i].value, true
+	}
+	return v, false
+}
+
+// Set adds or updates a value in the cache. Updating an existing key keeps
+// its slot and reference bit. If the cache is full and the key is new, an
+// entry is evicted to make room.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if i, ok := c.index[key]; ok {
+		c.slots[i].value = value
+		return
+	}
+	if c.size == 0 {
+		return
+	}
+	var i int32
+	switch {
+	case len(c.free) > 0:
+		i = c.free[len(c.free)-1]
+		c.free = c.free[:len(c.free)-1]
+	case len(c.slots) < c.size:
+		i = c.grow()
+	default:
+		i = c.evict()
+	}
+	s := &c.slots[i]
+	s.key, s.value = key, value
+	c.index[key] = i
+}
+
+// Remove deletes a key from the cache. It returns true if the key was present.
+func (c *Cache[K, V]) Remove(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i, ok := c.index[key]
+	if !ok {
+		return false
+	}
+	delete(c.index, key)
+	c.clear(i)
+	c.free = append(c.free, i)
+	return true
+}
+
+// Clear removes all entries from the cache.
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.index = map[K]int32{}
+	c.slots, c.free = nil, nil
+	c.hand = 0
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache[K, V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.index)
+}
+
+// evict moves the hand over the full buffer, clearing reference bits, and
+// empties the first slot whose bit was already clear, returning its index.
+func (c *Cache[K, V]) evict() int32 {
+	for {
+		i := c.hand
+		if c.hand++; int(c.hand) == len(c.slots) {
+			c.hand = 0
+		}
+		s := &c.slots[i]
+		if s.ref.Load() {
+			s.ref.Store(false)
+			continue
+		}
+		delete(c.index, s.key)
+		c.clear(i)
+		return i
+	}
+}
+
+// grow adds a slot to the buffer and returns its index. The buffer doubles
+// when it runs out of room, as with append, but never beyond size slots.
+func (c *Cache[K, V]) grow() int32 {
+	n := len(c.slots)
+	if n == cap(c.slots) {
+		m := 2 * n
+		if m < 16 {
+			m = 16
+		}
+		if m > c.size {
+			m = c.size
+		}
+		slots := make([]slot[K, V], n, m)
+		copy(slots, c.slots)
+		c.slots = slots
+	}
+	c.slots = c.slots[:n+1]
+	return int32(n)
+}
+
+// clear empties slot i so it does not retain its key and value.
+func (c *Cache[K, V]) clear(i int32) {
+	s := &c.slots[i]
+	var zero slot[K, V]
+	s.key, s.value = zero.key, zero.value
+	s.ref.Store(false)
+}
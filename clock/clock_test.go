@@ -0,0 +1,81 @@
+package clock
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tysonmote/cache/cachetest"
+)
+
+func TestCache(t *testing.T) {
+	err := cachetest.TestCache(func(size int) cachetest.Cache[int, int] {
+		return New[int, int](size)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSecondChance(t *testing.T) {
+	c := New[int, int](4)
+	for k := 0; k < 4; k++ {
+		c.Set(k, k)
+	}
+	c.Get(0)
+	c.Get(2)
+	// The hand clears 0's bit and evicts 1, then passes 2 to evict 3.
+	c.Set(4, 4)
+	c.Set(5, 5)
+	for k, want := range []bool{true, false, true, false, true, true} {
+		if _, ok := c.Peek(k); ok != want {
+			t.Fatalf("key %d: expected present %v, got %v", k, want, ok)
+		}
+	}
+	// Both 0 and 2 have used their second chance.
+	c.Set(6, 6)
+	if _, ok := c.Peek(0); ok {
+		t.Fatal("expected key 0 to be evicted once its bit was cleared")
+	}
+}
+
+func TestRemoveReusesSlots(t *testing.T) {
+	c := New[int, int](100)
+	for k := 0; k < 100; k++ {
+		c.Set(k, k)
+	}
+	for k := 0; k < 100; k += 2 {
+		c.Remove(k)
+	}
+	for k := 100; k < 150; k++ {
+		c.Set(k, k)
+	}
+	if len(c.slots) != 100 || cap(c.slots) != 100 || c.Len() != 100 {
+		t.Fatalf("expected 100 entries in 100 slots, got %d in %d (cap %d)", c.Len(), len(c.slots), cap(c.slots))
+	}
+	for k := 1; k < 100; k += 2 {
+		if _, ok := c.Peek(k); !ok {
+			t.Fatalf("expected key %d not to be evicted while slots were free", k)
+		}
+	}
+	c.Clear()
+	if c.Len() != 0 || len(c.slots) != 0 {
+		t.Fatal("expected Clear to empty the cache")
+	}
+}
+
+func TestRandomOps(t *testing.T) {
+	err := cachetest.TestRandomOps(New[int, int], 64, func(c *Cache[int, int]) error {
+		if n := c.Len(); n+len(c.free) != len(c.slots) {
+			return fmt.Errorf("expected one entry per used slot, got %d in %d slots with %d free", n, len(c.slots), len(c.free))
+		}
+		for k, i := range c.index {
+			if c.slots[i].key != k {
+				return fmt.Errorf("expected slot %d to hold key %d", i, k)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,347 @@
+package clockpro
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// nilSlot marks a missing link or hand.
+const nilSlot = -1
+
+type kind uint8
+
+const (
+	// cold entries are resident and were recently added or demoted; they are
+	// evicted unless referenced before the cold hand reaches them.
+	cold kind = iota
+	// hot entries are resident and were referenced while cold or while in
+	// their test period.
+	hot
+	// test entries were evicted while cold and keep only their key, so a
+	// key set again during its test period becomes hot.
+	test
+)
+
+// Cache is a thread-safe, fixed-size, in-memory cache with the CLOCK-Pro
+// eviction policy described in "CLOCK-Pro: An Effective Improvement of the
+// CLOCK Replacement" (Jiang et al., USENIX ATC 2005):
+// https://www.usenix.org/legacy/event/usenix05/tech/general/jiang.html
+//
+// Entries are kept on a single circle, each with a reference bit that Get
+// sets, and classified as hot, cold, or test. Three hands sweep the circle:
+// the cold hand evicts cold entries that were not referenced, keeping their
+// keys as test entries, and promotes referenced ones to hot; the hot hand
+// demotes hot entries that were not referenced since it last passed; and the
+// test hand forgets test entries that were not set again in time. A key set
+// again while it is a test entry is added as hot, and the share of the
+// capacity given to cold entries adapts: it grows when that happens and
+// shrinks when test entries expire. This distinguishes keys by how soon they
+// are reused, like LIRS, while a hit only sets a bit, so Get takes a read
+// lock.
+//
+// Entries, including up to size test entries, are stored by value in a slice
+// and linked into the circle by int32 slot indices rather than pointers, so
+// there are no per-entry allocations.
+type Cache[K comparable, V any] struct {
+	mu    sync.RWMutex
+	index map[K]int32
+	slots []slot[K, V]
+	free  []int32
+
+	hotHand, coldHand, testHand int32
+
+	size int
+	// coldSize is the target number of resident cold entries, from 1 to
+	// maxColdSize.
+	coldSize int
+
+	hotCount, coldCount, testCount int
+}
+
+type slot[K comparable, V any] struct {
+	key   K
+	value V
+	ref   atomic.Bool
+	kind  kind
+
+	// prev and next link the slot into the circle.
+	prev, next int32
+}
+
+// New returns a new Cache ready for use with a maximum capacity of size
+// items. A size of 0 disables caching: Set does not retain entries and Get
+// always misses. New panics if twice size does not fit in an int32.
+func New[K comparable, V any](size int) *Cache[K, V] {
+	if size < 0 {
+		panic("clockpro: size must not be negative")
+	}
+	if size > math.MaxInt32/2 {
+		panic("clockpro: size must be at most math.MaxInt32/2")
+	}
+	c := &Cache[K, V]{size: size}
+	c.reset()
+	return c
+}
+
+func (c *Cache[K, V]) reset() {
+	c.index = map[K]int32{}
+	c.slots, c.free = nil, nil
+	c.hotHand, c.coldHand, c.testHand = nilSlot, nilSlot, nilSlot
+	c.coldSize = c.maxColdSize()
+	c.hotCount, c.coldCount, c.testCount = 0, 0, 0
+}
+
+// Get returns a value from the cache if it exists. If the value does not
+// exist, ok is false.
+func (c *Cache[K, V]) Get(key K) (v V, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	i, ok := c.index[key]
+	if !ok {
+		return v, false
+	}
+	s := &c.slots[i]
+	if s.kind == test {
+		return v, false
+	}
+	if !s.ref.Load() {
+		s.ref.Store(true)
+	}
+	return s.value, true
+}
+
+// Peek returns a value from the cache if it exists, without setting its
+// reference bit. If the value does not exist, ok is false.
+func (c *Cache[K, V]) Peek(key K) (v V, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if i, ok := c.index[key]; ok && c.slots[i].kind != test {
+		return c.slots[i].value, true
+	}
+	return v, false
+}
+
+// Set adds or updates a value in the cache. Updating an existing key keeps
+// its place and reference bit. A new key is added as cold, or as hot if it
+// was evicted recently enough to still be a test entry. If the cache is full,
+// an entry is evicted to make room.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i, ok := c.index[key]
+	if ok && c.slots[i].kind != test {
+		c.slots[i].value = value
+		return
+	}
+	if c.size == 0 {
+		return
+	}
+	k := cold
+	if ok {
+		// The key was reused within its test period, so cold entries
+		// deserve more room.
+		if c.coldSize < c.maxColdSize() {
+			c.coldSize++
+		}
+		c.unlink(i)
+		c.testCount--
+		k = hot
+	}
+	// Each step moves at least one hand, and every lap of the cold hand
+	// evicts a cold entry or makes the hot hand demote one, so the loop ends.
+	for c.hotCount+c.coldCount >= c.size {
+		c.runCold()
+		for c.testCount > c.size {
+			c.runTest()
+		}
+		for c.hotCount > c.size-c.coldSize {
+			c.runHot()
+		}
+	}
+	i = c.alloc()
+	s := &c.slots[i]
+	s.key, s.value, s.kind = key, value, k
+	c.index[key] = i
+	c.link(i)
+	if k == hot {
+		c.hotCount++
+	} else {
+		c.coldCount++
+	}
+}
+
+// Remove deletes a key from the cache. It returns true if the key was present.
+// A test entry for the key is not affected.
+func (c *Cache[K, V]) Remove(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i, ok := c.index[key]
+	if !ok {
+		return false
+	}
+	switch c.slots[i].kind {
+	case test:
+		return false
+	case hot:
+		c.hotCount--
+	case cold:
+		c.coldCount--
+	}
+	c.unlink(i)
+	return true
+}
+
+// Clear removes all entries from the cache and forgets the test entries.
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reset()
+}
+
+// Len returns the number of entries in the cache, not counting test entries.
+func (c *Cache[K, V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.hotCount + c.coldCount
+}
+
+// maxColdSize returns the largest cold target, which leaves room for at least
+// one hot entry unless size is 1.
+func (c *Cache[K, V]) maxColdSize() int {
+	if c.size > 1 {
+		return c.size - 1
+	}
+	return c.size
+}
+
+// runCold advances the cold hand by one slot. A referenced cold entry becomes
+// hot; an unreferenced one is evicted and becomes a test entry.
+func (c *Cache[K, V]) runCold() {
+	s := &c.slots[c.coldHand]
+	if s.kind == cold {
+		if s.ref.Load() {
+			s.ref.Store(false)
+			s.kind = hot
+			c.coldCount--
+			c.hotCount++
+		} else {
+			var zero V
+			s.value, s.kind = zero, test
+			c.coldCount--
+			c.testCount++
+		}
+	}
+	c.coldHand = s.next
+}
+
+// runHot advances the hot hand by one slot, demoting a hot entry to cold
+// unless it was referenced since the hand last passed. A test hand on the
+// same slot is advanced first, so it stays ahead of the hot hand.
+func (c *Cache[K, V]) runHot() {
+	if c.hotHand == c.testHand {
+		c.runTest()
+	}
+	s := &c.slots[c.hotHand]
+	if s.kind == hot {
+		if s.ref.Load() {
+			s.ref.Store(false)
+		} else {
+			s.kind = cold
+			c.hotCount--
+			c.coldCount++
+		}
+	}
+	c.hotHand = s.next
+}
+
+// runTest advances the test hand by one slot, forgetting a test entry and
+// shrinking the cold target, since the key was not reused in time.
+func (c *Cache[K, V]) runTest() {
+	i := c.testHand
+	if c.slots[i].kind == test {
+		c.unlink(i)
+		c.testCount--
+		if c.coldSize > 1 {
+			c.coldSize--
+		}
+		// unlink moved the hand back to the previous slot.
+		if i = c.testHand; i == nilSlot {
+			return
+		}
+	}
+	c.testHand = c.slots[i].next
+}
+
+// alloc returns the index of an empty slot, growing the slice if none is
+// free. The slice doubles when it runs out of room, as with append, but never
+// beyond the 2×size slots that resident and test entries can fill.
+func (c *Cache[K, V]) alloc() int32 {
+	if n := len(c.free); n > 0 {
+		i := c.free[n-1]
+		c.free = c.free[:n-1]
+		return i
+	}
+	n := len(c.slots)
+	if n == cap(c.slots) {
+		m := 2 * n
+		if m < 16 {
+			m = 16
+		}
+		if m > 2*c.size {
+			m = 2 * c.size
+		}
+		slots := make([]slot[K, V], n, m)
+		copy(slots, c.slots)
+		c.slots = slots
+	}
+	c.slots = c.slots[:n+1]
+	return int32(n)
+}
+
+// link inserts slot i into the circle just behind the hot hand, which is the
+// head of the circle: the position every hand reaches last.
+func (c *Cache[K, V]) link(i int32) {
+	s := &c.slots[i]
+	if c.hotHand == nilSlot {
+		s.prev, s.next = i, i
+		c.hotHand, c.coldHand, c.testHand = i, i, i
+		return
+	}
+	next := c.hotHand
+	prev := c.slots[next].prev
+	s.prev, s.next = prev, next
+	c.slots[prev].next = i
+	c.slots[next].prev = i
+	if c.coldHand == c.hotHand {
+		c.coldHand = i
+	}
+}
+
+// unlink removes slot i from the circle and the index and frees it. A hand on
+// slot i moves back to the previous slot, so advancing it reaches the slot
+// that followed i.
+func (c *Cache[K, V]) unlink(i int32) {
+	s := &c.slots[i]
+	delete(c.index, s.key)
+	if s.next == i {
+		c.hotHand, c.coldHand, c.testHand = nilSlot, nilSlot, nilSlot
+	} else {
+		c.slots[s.prev].next = s.next
+		c.slots[s.next].prev = s.prev
+		if c.hotHand == i {
+			c.hotHand = s.prev
+		}
+		if c.coldHand == i {
+			c.coldHand = s.prev
+		}
+		if c.testHand == i {
+			c.testHand = s.prev
+		}
+	}
+	var zero slot[K, V]
+	s.key, s.value, s.kind = zero.key, zero.value, zero.kind
+	s.prev, s.next = nilSlot, nilSlot
+	s.ref.Store(false)
+	c.free = append(c.free, i)
+}
@@ -0,0 +1,146 @@
+package clockpro
+
+import (
+	"testing"
+
+	"github.com/tysonmote/cache/cachetest"
+)
+
+func TestCache(t *testing.T) {
+	err := cachetest.TestCache(func(size int) cachetest.Cache[int, int] {
+		return New[int, int](size)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// check verifies that the circle links every indexed slot exactly once and
+// that the counts of each kind match it.
+func check[K comparable, V any](t *testing.T, c *Cache[K, V]) {
+	t.Helper()
+	counts := map[kind]int{}
+	if c.hotHand != nilSlot {
+		i := c.hotHand
+		for {
+			s := &c.slots[i]
+			if j, ok := c.index[s.key]; !ok || j != i {
+				t.Fatalf("slot %d is on the circle but not indexed", i)
+			}
+			if c.slots[s.next].prev != i {
+				t.Fatalf("slot %d is mislinked", i)
+			}
+			counts[s.kind]++
+			if i = s.next; i == c.hotHand {
+				break
+			}
+		}
+	}
+	if n := counts[hot] + counts[cold] + counts[test]; n != len(c.index) {
+		t.Fatalf("expected %d slots on the circle, got %d", len(c.index), n)
+	}
+	if counts[hot] != c.hotCount || counts[cold] != c.coldCount || counts[test] != c.testCount {
+		t.Fatalf("expected %d hot, %d cold, and %d test entries, counted %d, %d, and %d",
+			c.hotCount, c.coldCount, c.testCount, counts[hot], counts[cold], counts[test])
+	}
+	if c.hotCount+c.coldCount > c.size || c.testCount > c.size || len(c.slots) > 2*c.size {
+		t.Fatalf("expected at most %d resident and %d test entries in %d slots, got %d, %d, and %d",
+			c.size, c.size, 2*c.size, c.hotCount+c.coldCount, c.testCount, len(c.slots))
+	}
+}
+
+func TestSizeOne(t *testing.T) {
+	c := New[int, int](1)
+	c.Set(1, 1)
+	c.Get(1)
+	// The referenced entry is promoted to hot and, with no room for hot
+	// entries, demoted and evicted.
+	c.Set(2, 2)
+	if _, ok := c.Peek(1); ok {
+		t.Fatal("expected key 1 to be evicted")
+	}
+	if v, ok := c.Get(2); !ok || v != 2 {
+		t.Fatal("expected a hit on key 2")
+	}
+	c.Set(1, 1)
+	c.Get(1)
+	c.Set(3, 3)
+	check(t, c)
+	if c.Len() != 1 {
+		t.Fatalf("expected 1 entry, got %d", c.Len())
+	}
+}
+
+func TestTestEntryBecomesHot(t *testing.T) {
+	c := New[int, int](4)
+	for k := 0; k < 5; k++ {
+		c.Set(k, k)
+	}
+	// One key was evicted but is remembered as a test entry.
+	evicted := -1
+	for k := 0; k < 5; k++ {
+		if _, ok := c.Get(k); !ok {
+			evicted = k
+		}
+	}
+	if i, ok := c.index[evicted]; !ok || c.slots[i].kind != test || c.Len() != 4 {
+		t.Fatal("expected one key to be evicted to a test entry")
+	}
+	if c.Remove(evicted) {
+		t.Fatal("expected Remove to ignore a test entry")
+	}
+	c.Set(evicted, evicted)
+	if i := c.index[evicted]; c.slots[i].kind != hot {
+		t.Fatal("expected the evicted key to be added again as hot")
+	}
+	if v, ok := c.Get(evicted); !ok || v != evicted {
+		t.Fatal("expected a hit on the evicted key")
+	}
+	check(t, c)
+	c.Clear()
+	if c.Len() != 0 || len(c.index) != 0 {
+		t.Fatal("expected Clear to forget every entry")
+	}
+}
+
+func TestScanResistance(t *testing.T) {
+	c := New[int, int](100)
+	// Hot keys are reused within their test period and become hot.
+	for i := 0; i < 10; i++ {
+		for k := 0; k < 50; k++ {
+			if _, ok := c.Get(k); !ok {
+				c.Set(k, k)
+			}
+		}
+		for k := 0; k < 100; k++ {
+			c.Set(-1-i*100-k, k)
+		}
+	}
+	// A scan of one-off keys, interleaved with reads of the hot keys,
+	// churns only cold entries.
+	for k := 1000; k < 10_000; k++ {
+		c.Set(k, k)
+		for h := k % 7; h < 50; h += 7 {
+			c.Get(h)
+		}
+	}
+	for k := 0; k < 50; k++ {
+		if _, ok := c.Peek(k); !ok {
+			t.Fatalf("expected hot key %d to survive the scan", k)
+		}
+	}
+	if c.Len() != 100 {
+		t.Fatalf("expected a full cache, got %d entries", c.Len())
+	}
+	check(t, c)
+}
+
+func TestRandomOps(t *testing.T) {
+	err := cachetest.TestRandomOps(New[int, int], 64, func(c *Cache[int, int]) error {
+		check(t, c)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
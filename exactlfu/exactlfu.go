@@ -0,0 +1,290 @@
+package exactlfu
+
+import (
+	"sync"
+
+	"github.com/tysonmote/cache/internal/keyhash"
+	"github.com/tysonmote/cache/internal/split"
+)
+
+// Cache is a thread-safe, fixed-size, in-memory cache with an exact
+// least-frequently-used eviction policy, implemented with the frequency lists
+// described in "An O(1) algorithm for implementing the LFU cache eviction
+// scheme" (Shah et al., 2010): https://arxiv.org/pdf/2110.11602.pdf
+//
+// Each shard keeps a doubly linked list of frequency nodes in increasing
+// order of access count, and each node holds the entries with that count in
+// a list of its own, oldest first. A Get moves its entry to the node for the
+// next count, creating that node if needed, and eviction takes the oldest
+// entry of the first node, so every operation takes constant time. Unlike
+// lfu, the entry evicted is always one of the least frequently used, at the
+// cost of an exclusive lock on every Get and two list nodes' worth of
+// pointers per entry.
+//
+// As in lfu, overwriting an existing key with Set resets its count to one
+// access, and a Cache is implemented as one or more shards, each with its own
+// lock: New uses a single shard, and NewSharded up to numShards. Eviction is
+// local to each shard.
+type Cache[K comparable, V any] struct {
+	shards []*shard[K, V]
+	keys   *keyhash.Func[K]
+
+	// resizeMu serializes Resize calls.
+	resizeMu sync.Mutex
+}
+
+type shard[K comparable, V any] struct {
+	mu      sync.Mutex
+	cap     int
+	entries map[K]*entry[K, V]
+
+	// head is the node with the lowest count, or nil if the shard is empty.
+	head *freqNode[K, V]
+}
+
+// freqNode holds the entries accessed count times, linked from oldest to
+// newest, and links to the nodes with the next lower and higher counts.
+type freqNode[K comparable, V any] struct {
+	count      uint64
+	oldest     *entry[K, V]
+	newest     *entry[K, V]
+	prev, next *freqNode[K, V]
+}
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+	node  *freqNode[K, V]
+
+	// prev is the next older entry in node and next the next newer one.
+	prev, next *entry[K, V]
+}
+
+// New returns a new Cache ready for use with a maximum capacity of size
+// items. A size of 0 disables caching: Set does not retain entries and Get
+// always misses.
+func New[K comparable, V any](size int) *Cache[K, V] {
+	return NewSharded[K, V](size, 1)
+}
+
+// NewSharded returns a cache with up to numShards shards, each with its own
+// lock, so concurrent operations on different keys can proceed in parallel.
+// The capacity is split as in lfu's WithShards, so the cache may hold
+// somewhat more than size entries in total. NewSharded panics if numShards is
+// less than 1.
+func NewSharded[K comparable, V any](size, numShards int) *Cache[K, V] {
+	if size < 0 {
+		panic("exactlfu: size must not be negative")
+	}
+	if numShards < 1 {
+		panic("exactlfu: numShards must be at least 1")
+	}
+	n := split.Shards(size, numShards)
+	c := &Cache[K, V]{
+		shards: make([]*shard[K, V], n),
+		keys:   keyhash.New[K](),
+	}
+	cap := split.Capacity(size, n, false)
+	for i := range c.shards {
+		c.shards[i] = &shard[K, V]{cap: cap, entries: map[K]*entry[K, V]{}}
+	}
+	return c
+}
+
+func (c *Cache[K, V]) shard(key K) *shard[K, V] {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	return c.shards[c.keys.Sum64(key)%uint64(len(c.shards))]
+}
+
+// Get returns a value from the cache if it exists, counting an access to it.
+// If the value does not exist, ok is false.
+func (c *Cache[K, V]) Get(key K) (v V, ok bool) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return v, false
+	}
+	s.increment(e)
+	return e.value, true
+}
+
+// Peek returns a value from the cache if it exists, without counting an
+// access. If the value does not exist, ok is false.
+func (c *Cache[K, V]) Peek(key K) (v V, ok bool) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		return e.value, true
+	}
+	return v, false
+}
+
+// Set adds or updates a value in the cache with a count of one access. If the
+// cache is full and the key is new, the least frequently used entry is
+// evicted to make room; among entries with the same count, the one that
+// reached that count first is evicted.
+func (c *Cache[K, V]) Set(key K, value V) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		e.value = value
+		s.unlink(e)
+		s.insertAfter(e, nil, 1)
+		return
+	}
+	if s.cap == 0 {
+		return
+	}
+	for len(s.entries) >= s.cap {
+		s.evict()
+	}
+	e := &entry[K, V]{key: key, value: value}
+	s.entries[key] = e
+	s.insertAfter(e, nil, 1)
+}
+
+// Remove deletes a key from the cache. It returns true if the key was present.
+func (c *Cache[K, V]) Remove(key K) bool {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if ok {
+		s.unlink(e)
+		delete(s.entries, key)
+	}
+	return ok
+}
+
+// Clear removes all entries from the cache.
+func (c *Cache[K, V]) Clear() {
+	for _, s := range c.shards {
+		s.mu.Lock()
+		s.entries = map[K]*entry[K, V]{}
+		s.head = nil
+		s.mu.Unlock()
+	}
+}
+
+// Resize changes the maximum capacity of the cache, splitting it across the
+// existing shards as NewSharded does. When shrinking, each shard evicts its
+// least frequently used entries until it fits its new limit. A size of 0
+// empties the cache and disables caching.
+func (c *Cache[K, V]) Resize(size int) {
+	if size < 0 {
+		panic("exactlfu: size must not be negative")
+	}
+	c.resizeMu.Lock()
+	defer c.resizeMu.Unlock()
+	cap := split.Capacity(size, len(c.shards), false)
+	for _, s := range c.shards {
+		s.mu.Lock()
+		s.cap = cap
+		for len(s.entries) > s.cap {
+			s.evict()
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache[K, V]) Len() int {
+	n := 0
+	for _, s := range c.shards {
+		s.mu.Lock()
+		n += len(s.entries)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// increment moves e to the node for the next higher count.
+func (s *shard[K, V]) increment(e *entry[K, V]) {
+	from := e.node
+	count := from.count + 1
+	if from.newest == e && from.oldest == e {
+		// e is the node's only entry, so the node can take the new count
+		// unless the next node already has it.
+		if from.next == nil || from.next.count != count {
+			from.count = count
+			return
+		}
+	}
+	s.unlink(e)
+	// unlink may have removed from, but its links still lead to its
+	// neighbors.
+	prev := from
+	if from.oldest == nil {
+		prev = from.prev
+	}
+	s.insertAfter(e, prev, count)
+}
+
+// insertAfter adds e as the newest entry of the node for count, which follows
+// prev, or is the head if prev is nil. The node is created if needed.
+func (s *shard[K, V]) insertAfter(e *entry[K, V], prev *freqNode[K, V], count uint64) {
+	next := s.head
+	if prev != nil {
+		next = prev.next
+	}
+	n := next
+	if n == nil || n.count != count {
+		n = &freqNode[K, V]{count: count, prev: prev, next: next}
+		if prev != nil {
+			prev.next = n
+		} else {
+			s.head = n
+		}
+		if next != nil {
+			next.prev = n
+		}
+	}
+	e.node = n
+	e.prev, e.next = n.newest, nil
+	if n.newest != nil {
+		n.newest.next = e
+	} else {
+		n.oldest = e
+	}
+	n.newest = e
+}
+
+// unlink removes e from its node, and removes the node if it is left empty.
+func (s *shard[K, V]) unlink(e *entry[K, V]) {
+	n := e.node
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		n.oldest = e.next
+	}
+	if e.next != nil {
+		e.next.prev = e.prev
+	} else {
+		n.newest = e.prev
+	}
+	e.prev, e.next, e.node = nil, nil, nil
+	if n.oldest != nil {
+		return
+	}
+	if n.prev != nil {
+		n.prev.next = n.next
+	} else {
+		s.head = n.next
+	}
+	if n.next != nil {
+		n.next.prev = n.prev
+	}
+}
+
+// evict removes the oldest entry with the lowest count.
+func (s *shard[K, V]) evict() {
+	e := s.head.oldest
+	s.unlink(e)
+	delete(s.entries, e.key)
+}
@@ -0,0 +1,148 @@
+package exactlfu
+
+import (
+	"testing"
+
+	"github.com/tysonmote/cache/cachetest"
+)
+
+func TestCache(t *testing.T) {
+	err := cachetest.TestCache(func(size int) cachetest.Cache[int, int] {
+		return New[int, int](size)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShardedCache(t *testing.T) {
+	err := cachetest.TestCache(func(size int) cachetest.Cache[int, int] {
+		return NewSharded[int, int](size, 16)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// counts returns the count of each node of s, lowest first, and checks that
+// each entry points to the node that holds it.
+func counts[K comparable, V any](t *testing.T, s *shard[K, V]) []uint64 {
+	t.Helper()
+	var out []uint64
+	n := 0
+	for f := s.head; f != nil; f = f.next {
+		if f.oldest == nil {
+			t.Fatalf("node for count %d is empty", f.count)
+		}
+		if f.next != nil && (f.next.count <= f.count || f.next.prev != f) {
+			t.Fatalf("node for count %d is misordered or mislinked", f.count)
+		}
+		for e := f.oldest; e != nil; e = e.next {
+			if e.node != f {
+				t.Fatalf("entry %v does not point to its node", e.key)
+			}
+			n++
+		}
+		out = append(out, f.count)
+	}
+	if n != len(s.entries) {
+		t.Fatalf("expected %d entries in nodes, got %d", len(s.entries), n)
+	}
+	return out
+}
+
+func TestEvictsLeastFrequent(t *testing.T) {
+	c := New[int, int](5)
+	for k := 0; k < 5; k++ {
+		c.Set(k, k)
+		for i := 0; i < k; i++ {
+			c.Get(k)
+		}
+	}
+	if got := counts(t, c.shards[0]); len(got) != 5 || got[0] != 1 || got[4] != 5 {
+		t.Fatalf("expected counts 1 to 5, got %v", got)
+	}
+	// Each new key evicts the least frequently used key, which is the
+	// previous new key once the original key 0 is gone.
+	for k := 5; k < 10; k++ {
+		c.Set(k, k)
+	}
+	for k, want := range []bool{false, true, true, true, true, false, false, false, false, true} {
+		if _, ok := c.Peek(k); ok != want {
+			t.Fatalf("key %d: expected present %v, got %v", k, want, ok)
+		}
+	}
+}
+
+func TestTiesEvictOldest(t *testing.T) {
+	c := New[int, int](3)
+	c.Set(1, 1)
+	c.Set(2, 2)
+	c.Set(3, 3)
+	c.Get(2)
+	c.Get(1)
+	// Keys 1 and 2 both have two accesses; 2 reached it first.
+	c.Get(3)
+	c.Get(3)
+	c.Set(4, 4)
+	if _, ok := c.Peek(2); ok {
+		t.Fatal("expected key 2 to be evicted")
+	}
+	if got := counts(t, c.shards[0]); len(got) != 3 {
+		t.Fatalf("expected three nodes, got %v", got)
+	}
+}
+
+func TestSetResetsCount(t *testing.T) {
+	c := New[int, int](2)
+	c.Set(1, 1)
+	c.Set(2, 2)
+	c.Get(1)
+	c.Get(1)
+	c.Get(2)
+	c.Set(1, 10)
+	c.Set(3, 3)
+	if _, ok := c.Peek(1); ok {
+		t.Fatal("expected overwritten key 1 to be evicted")
+	}
+	if v, _ := c.Peek(2); v != 2 {
+		t.Fatal("expected key 2 to remain")
+	}
+}
+
+func TestResize(t *testing.T) {
+	c := New[int, int](100)
+	for k := 0; k < 100; k++ {
+		c.Set(k, k)
+		if k%2 == 0 {
+			c.Get(k)
+		}
+	}
+	c.Resize(50)
+	if c.Len() != 50 {
+		t.Fatalf("expected 50 entries, got %d", c.Len())
+	}
+	for k := 0; k < 100; k += 2 {
+		if _, ok := c.Peek(k); !ok {
+			t.Fatalf("expected frequently used key %d to remain", k)
+		}
+	}
+	c.Resize(0)
+	c.Set(1, 1)
+	if c.Len() != 0 {
+		t.Fatal("expected Resize(0) to disable caching")
+	}
+}
+
+func TestRandomOps(t *testing.T) {
+	newSharded := func(size int) *Cache[int, int] { return NewSharded[int, int](size, 16) }
+	err := cachetest.TestRandomOps(newSharded, 64, func(c *Cache[int, int]) error {
+		for _, s := range c.shards {
+			counts(t, s)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,311 @@
+// Package keyhash hashes comparable keys for shard routing without
+// allocating, for the caches in this module.
+package keyhash
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/maphash"
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+// Func hashes keys of type K. It uses maphash with a random seed unless it
+// was created by NewSeeded, in which case it uses FNV-1a starting from the
+// seed, whose output does not vary between processes.
+type Func[K comparable] struct {
+	seed   maphash.Seed
+	seeded bool
+	sum    uint64
+
+	// layout encodes keys that are not of a predeclared type (see
+	// keyLayout).
+	layout []keyField
+}
+
+// New returns a Func with a random seed.
+func New[K comparable]() *Func[K] {
+	return newFunc[K](maphash.MakeSeed(), false, 0)
+}
+
+// NewSeeded returns a Func whose hashes are determined by seed.
+func NewSeeded[K comparable](seed uint64) *Func[K] {
+	return newFunc[K](maphash.Seed{}, true, seed)
+}
+
+func newFunc[K comparable](seed maphash.Seed, seeded bool, fixed uint64) *Func[K] {
+	f := &Func[K]{seed: seed, seeded: seeded, sum: fnvOffset ^ Mix64(fixed)}
+	if !predeclaredKey[K]() {
+		f.layout, _ = keyLayout(reflect.TypeOf((*K)(nil)).Elem())
+	}
+	return f
+}
+
+// Sum64 returns the hash of key.
+func (f *Func[K]) Sum64(key K) uint64 {
+	h := keyHasher{seeded: f.seeded, sum: f.sum}
+	if !f.seeded {
+		h.mh.SetSeed(f.seed)
+	}
+	writeKey(&h, key, f.layout)
+	return h.sum64()
+}
+
+// Seed returns a 64-bit seed derived from f's seed, for hash functions that
+// replace f.
+func (f *Func[K]) Seed() uint64 {
+	if f.seeded {
+		return Mix64(f.sum)
+	}
+	return maphash.Bytes(f.seed, nil)
+}
+
+// keyHasher accumulates the hash of one key.
+type keyHasher struct {
+	mh     maphash.Hash
+	seeded bool
+	sum    uint64
+}
+
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+func (h *keyHasher) write(b []byte) {
+	if !h.seeded {
+		h.mh.Write(b)
+		return
+	}
+	for _, c := range b {
+		h.sum = (h.sum ^ uint64(c)) * fnvPrime
+	}
+}
+
+func (h *keyHasher) writeByte(c byte) {
+	if !h.seeded {
+		h.mh.WriteByte(c)
+		return
+	}
+	h.sum = (h.sum ^ uint64(c)) * fnvPrime
+}
+
+func (h *keyHasher) writeString(s string) {
+	if !h.seeded {
+		h.mh.WriteString(s)
+		return
+	}
+	for i := 0; i < len(s); i++ {
+		h.sum = (h.sum ^ uint64(s[i])) * fnvPrime
+	}
+}
+
+func (h *keyHasher) sum64() uint64 {
+	if !h.seeded {
+		return h.mh.Sum64()
+	}
+	// FNV's low bits, which pick the shard, mix poorly on their own.
+	return Mix64(h.sum)
+}
+
+// Mix64 is the splitmix64 finalizer.
+func Mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// writeKey writes key to h. Keys of predeclared types are encoded directly.
+// Other keys are encoded using layout, from keyLayout, if it is not nil, or
+// else formatted with fmt, which allocates.
+func writeKey[K comparable](h *keyHasher, key K, layout []keyField) {
+	if layout != nil {
+		writeLayout(h, unsafe.Pointer(&key), layout)
+		return
+	}
+	switch k := any(key).(type) {
+	case int:
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], uint64(int64(k)))
+		h.write(b[:])
+	case int8:
+		h.writeByte(byte(k))
+	case int16:
+		var b [2]byte
+		binary.LittleEndian.PutUint16(b[:], uint16(k))
+		h.write(b[:])
+	case int32:
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], uint32(k))
+		h.write(b[:])
+	case int64:
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], uint64(k))
+		h.write(b[:])
+	case uint:
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], uint64(k))
+		h.write(b[:])
+	case uint8:
+		h.writeByte(k)
+	case uint16:
+		var b [2]byte
+		binary.LittleEndian.PutUint16(b[:], k)
+		h.write(b[:])
+	case uint32:
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], k)
+		h.write(b[:])
+	case uint64:
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], k)
+		h.write(b[:])
+	case uintptr:
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], uint64(k))
+		h.write(b[:])
+	case string:
+		h.writeString(k)
+	case bool:
+		if k {
+			h.writeByte(1)
+		} else {
+			h.writeByte(0)
+		}
+	case float32:
+		h.writeUint64(uint64(math.Float32bits(k + 0)))
+	case float64:
+		h.writeUint64(math.Float64bits(k + 0))
+	case complex64:
+		h.writeUint64(uint64(math.Float32bits(real(k) + 0)))
+		h.writeUint64(uint64(math.Float32bits(imag(k) + 0)))
+	case complex128:
+		h.writeUint64(math.Float64bits(real(k) + 0))
+		h.writeUint64(math.Float64bits(imag(k) + 0))
+	default:
+		h.writeString(fmt.Sprintf("%T:%v", key, key))
+	}
+}
+
+func (h *keyHasher) writeUint64(x uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], x)
+	h.write(b[:])
+}
+
+// predeclaredKey reports whether writeKey encodes K without a layout.
+func predeclaredKey[K comparable]() bool {
+	var zero K
+	switch any(zero).(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr,
+		string, bool, float32, float64, complex64, complex128:
+		return true
+	}
+	return false
+}
+
+// keyField is a part of a key that writeLayout hashes: size bytes of raw
+// memory, a string, or a float, at offset off.
+type keyField struct {
+	off  uintptr
+	size uintptr
+	kind keyFieldKind
+}
+
+type keyFieldKind uint8
+
+const (
+	fieldRaw keyFieldKind = iota
+	fieldString
+	fieldFloat32
+	fieldFloat64
+)
+
+// keyLayout returns the fields writeLayout hashes for keys of type t, so that
+// arrays, structs, and named types are hashed without reflection or
+// allocation. Only the parts of a key that == compares are included: padding
+// and blank fields are skipped, strings are hashed by content, and floats are
+// hashed so that -0 and +0 collide. Raw memory is hashed in native byte order.
+// ok is false if t contains an interface, whose dynamic value has no fixed
+// layout.
+func keyLayout(t reflect.Type) (layout []keyField, ok bool) {
+	layout, ok = appendLayout(nil, t, 0)
+	if ok && layout == nil {
+		// An empty layout, for a zero-size key, must still be non-nil.
+		layout = []keyField{}
+	}
+	return layout, ok
+}
+
+func appendLayout(dst []keyField, t reflect.Type, off uintptr) ([]keyField, bool) {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Pointer, reflect.UnsafePointer, reflect.Chan:
+		if t.Size() == 0 {
+			return dst, true
+		}
+		// Merge with the previous field if they are adjacent in memory.
+		if n := len(dst); n > 0 && dst[n-1].kind == fieldRaw && dst[n-1].off+dst[n-1].size == off {
+			dst[n-1].size += t.Size()
+			return dst, true
+		}
+		return append(dst, keyField{off: off, size: t.Size(), kind: fieldRaw}), true
+	case reflect.String:
+		return append(dst, keyField{off: off, kind: fieldString}), true
+	case reflect.Float32:
+		return append(dst, keyField{off: off, kind: fieldFloat32}), true
+	case reflect.Float64:
+		return append(dst, keyField{off: off, kind: fieldFloat64}), true
+	case reflect.Complex64:
+		return append(dst, keyField{off: off, kind: fieldFloat32}, keyField{off: off + 4, kind: fieldFloat32}), true
+	case reflect.Complex128:
+		return append(dst, keyField{off: off, kind: fieldFloat64}, keyField{off: off + 8, kind: fieldFloat64}), true
+	case reflect.Array:
+		elem := t.Elem()
+		for i := 0; i < t.Len(); i++ {
+			var ok bool
+			if dst, ok = appendLayout(dst, elem, off+uintptr(i)*elem.Size()); !ok {
+				return nil, false
+			}
+		}
+		return dst, true
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.Name == "_" {
+				continue
+			}
+			var ok bool
+			if dst, ok = appendLayout(dst, f.Type, off+f.Offset); !ok {
+				return nil, false
+			}
+		}
+		return dst, true
+	}
+	return nil, false
+}
+
+// writeLayout writes the fields of the key at p to h.
+func writeLayout(h *keyHasher, p unsafe.Pointer, layout []keyField) {
+	for _, f := range layout {
+		q := unsafe.Add(p, f.off)
+		switch f.kind {
+		case fieldRaw:
+			h.write(unsafe.Slice((*byte)(q), f.size))
+		case fieldString:
+			s := *(*string)(q)
+			// The length keeps {"ab", ""} and {"a", "b"} apart.
+			h.writeUint64(uint64(len(s)))
+			h.writeString(s)
+		case fieldFloat32:
+			h.writeUint64(uint64(math.Float32bits(*(*float32)(q) + 0)))
+		case fieldFloat64:
+			h.writeUint64(math.Float64bits(*(*float64)(q) + 0))
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Package list implements an intrusive doubly linked list, for the caches in
+// this module that keep their entries in FIFO or LRU order.
+package list
+
+// Link holds the pointers that link a T into a List. T embeds a Link, so an
+// entry needs no separate list element and no allocation of its own.
+type Link[T any] struct {
+	prev, next *T
+}
+
+// Prev returns the entry pushed after this one, toward the front of the list,
+// or nil if it is the front.
+func (l *Link[T]) Prev() *T {
+	return l.prev
+}
+
+func (l *Link[T]) link() *Link[T] {
+	return l
+}
+
+// Node is satisfied by a pointer to a type that embeds Link[T].
+type Node[T any] interface {
+	*T
+	link() *Link[T]
+}
+
+// List is a list of entries linked through their embedded Links. Entries are
+// pushed at the front and popped from the back, so the back is the oldest
+// entry. The zero value is an empty list ready for use.
+type List[T any, P Node[T]] struct {
+	front, back P
+	len         int
+}
+
+// Len returns the number of entries in the list.
+func (l *List[T, P]) Len() int {
+	return l.len
+}
+
+// Back returns the oldest entry, or nil if the list is empty.
+func (l *List[T, P]) Back() P {
+	return l.back
+}
+
+// Push adds e, which must not be in a list, at the front.
+func (l *List[T, P]) Push(e P) {
+	k := e.link()
+	k.prev, k.next = nil, l.front
+	if l.front != nil {
+		P(l.front).link().prev = e
+	} else {
+		l.back = e
+	}
+	l.front = e
+	l.len++
+}
+
+// Pop removes and returns the entry at the back. The list must not be empty.
+func (l *List[T, P]) Pop() P {
+	e := l.back
+	l.Remove(e)
+	return e
+}
+
+// Remove removes e, which must be in the list.
+func (l *List[T, P]) Remove(e P) {
+	k := e.link()
+	if k.prev != nil {
+		P(k.prev).link().next = k.next
+	} else {
+		l.front = k.next
+	}
+	if k.next != nil {
+		P(k.next).link().prev = k.prev
+	} else {
+		l.back = k.prev
+	}
+	k.prev, k.next = nil, nil
+	l.len--
+}
@@ -0,0 +1,33 @@
+package list
+
+import "testing"
+
+type item struct {
+	n int
+	Link[item]
+}
+
+func TestList(t *testing.T) {
+	var l List[item, *item]
+	items := make([]item, 4)
+	for i := range items {
+		items[i].n = i
+		l.Push(&items[i])
+	}
+	l.Remove(&items[2])
+	if l.Len() != 3 || l.Back() != &items[0] || items[0].Prev() != &items[1] || items[1].Prev() != &items[3] {
+		t.Fatal("expected the list to hold 3, 1, 0 from front to back")
+	}
+	for _, want := range []int{0, 1, 3} {
+		if e := l.Pop(); e.n != want {
+			t.Fatalf("expected to pop %d, got %d", want, e.n)
+		}
+	}
+	if l.Len() != 0 || l.Back() != nil {
+		t.Fatal("expected the list to be empty")
+	}
+	l.Push(&items[0])
+	if l.Back() != &items[0] || items[0].Prev() != nil {
+		t.Fatal("expected a popped entry to be reusable")
+	}
+}
@@ -0,0 +1,96 @@
+// Package sketch provides compact probabilistic frequency estimators shared by
+// the cache implementations in this repository.
+package sketch
+
+import (
+	"math/bits"
+)
+
+// depth is the number of rows in a CountMin. Each key increments one counter
+// per row, and its estimate is the minimum of those counters.
+const depth = 4
+
+// maxCount is the largest value a 4-bit counter holds.
+const maxCount = 15
+
+// rowSeeds are odd multipliers that derive an independent counter index per
+// row from a key's hash.
+var rowSeeds = [depth]uint64{
+	0x9e3779b97f4a7c15,
+	0xc2b2ae3d27d4eb4f,
+	0x165667b19e3779f9,
+	0xd6e8feb86659fd93,
+}
+
+// CountMin is a count-min sketch with 4-bit saturating counters, as used by
+// TinyLFU. After a sample of increments proportional to its width, every
+// counter is halved so that old popularity fades.
+type CountMin struct {
+	rows    [depth][]uint64 // 16 counters per word
+	mask    uint64
+	adds    int
+	resetAt int
+}
+
+// NewCountMin returns a sketch sized for about width distinct keys, rounded up
+// to a power of two. Counters are halved after 10×width increments.
+func NewCountMin(width int) *CountMin {
+	if width < 16 {
+		width = 16
+	}
+	width = 1 << bits.Len(uint(width-1))
+	c := &CountMin{
+		mask:    uint64(width - 1),
+		resetAt: 10 * width,
+	}
+	for i := range c.rows {
+		c.rows[i] = make([]uint64, width/16)
+	}
+	return c
+}
+
+// Increment counts one occurrence of the key with hash h. It reports whether
+// the increment triggered a reset that halved every counter.
+func (c *CountMin) Increment(h uint64) bool {
+	for i := range c.rows {
+		idx := c.index(h, i)
+		word, shift := idx/16, (idx%16)*4
+		if (c.rows[i][word]>>shift)&0xf < maxCount {
+			c.rows[i][word] += 1 << shift
+		}
+	}
+	c.adds++
+	if c.adds >= c.resetAt {
+		c.Reset()
+		return true
+	}
+	return false
+}
+
+// Estimate returns the estimated number of occurrences of the key with hash h,
+// at most 15.
+func (c *CountMin) Estimate(h uint64) int {
+	min := uint64(maxCount)
+	for i := range c.rows {
+		idx := c.index(h, i)
+		if n := (c.rows[i][idx/16] >> ((idx % 16) * 4)) & 0xf; n < min {
+			min = n
+		}
+	}
+	return int(min)
+}
+
+// Reset halves every counter.
+func (c *CountMin) Reset() {
+	for _, row := range c.rows {
+		for j := range row {
+			row[j] = (row[j] >> 1) & 0x7777777777777777
+		}
+	}
+	c.adds /= 2
+}
+
+func (c *CountMin) index(h uint64, row int) uint64 {
+	h *= rowSeeds[row]
+	return (h ^ h>>32) & c.mask
+}
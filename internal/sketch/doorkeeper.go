@@ -0,0 +1,64 @@
+package sketch
+
+import (
+	"math/bits"
+)
+
+// Doorkeeper is a Bloom filter placed in front of a CountMin so that keys seen
+// only once do not occupy sketch counters. It is cleared whenever the sketch
+// resets.
+type Doorkeeper struct {
+	bits []uint64
+	mask uint64
+}
+
+// NewDoorkeeper returns a filter with about 8 bits per expected key, rounded
+// up to a power of two.
+func NewDoorkeeper(keys int) *Doorkeeper {
+	n := 8 * keys
+	if n < 64 {
+		n = 64
+	}
+	n = 1 << bits.Len(uint(n-1))
+	return &Doorkeeper{
+		bits: make([]uint64, n/64),
+		mask: uint64(n - 1),
+	}
+}
+
+// Insert adds the key with hash h and reports whether it was already present.
+func (d *Doorkeeper) Insert(h uint64) bool {
+	present := true
+	for i := 0; i < 3; i++ {
+		idx := d.index(h, i)
+		word, bit := idx/64, uint64(1)<<(idx%64)
+		if d.bits[word]&bit == 0 {
+			present = false
+			d.bits[word] |= bit
+		}
+	}
+	return present
+}
+
+// Contains reports whether the key with hash h may have been inserted.
+func (d *Doorkeeper) Contains(h uint64) bool {
+	for i := 0; i < 3; i++ {
+		idx := d.index(h, i)
+		if d.bits[idx/64]&(1<<(idx%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Reset clears the filter.
+func (d *Doorkeeper) Reset() {
+	for i := range d.bits {
+		d.bits[i] = 0
+	}
+}
+
+func (d *Doorkeeper) index(h uint64, i int) uint64 {
+	h *= rowSeeds[i]
+	return (h ^ h>>29) & d.mask
+}
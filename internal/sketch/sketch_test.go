@@ -0,0 +1,104 @@
+package sketch
+
+import (
+	"testing"
+)
+
+// hash spreads small integers over 64 bits like a real key hash would.
+func hash(i int) uint64 {
+	h := uint64(i) * 0x9e3779b97f4a7c15
+	return h ^ h>>31
+}
+
+func TestCountMin(t *testing.T) {
+	c := NewCountMin(1024)
+	for i := 0; i < 5; i++ {
+		c.Increment(hash(1))
+	}
+	for i := 0; i < 100; i++ {
+		c.Increment(hash(2))
+	}
+	if n := c.Estimate(hash(1)); n != 5 {
+		t.Fatalf("expected estimate 5, got %d", n)
+	}
+	if n := c.Estimate(hash(2)); n != maxCount {
+		t.Fatalf("expected saturated estimate %d, got %d", maxCount, n)
+	}
+	if n := c.Estimate(hash(3)); n != 0 {
+		t.Fatalf("expected estimate 0 for unseen key, got %d", n)
+	}
+	c.Reset()
+	if n := c.Estimate(hash(1)); n != 2 {
+		t.Fatalf("expected estimate halved to 2, got %d", n)
+	}
+}
+
+func TestCountMinPeriodicReset(t *testing.T) {
+	c := NewCountMin(16)
+	resets := 0
+	for i := 0; i < 1000; i++ {
+		if c.Increment(hash(i)) {
+			resets++
+		}
+	}
+	if resets == 0 {
+		t.Fatal("expected counters to be reset after a sample of increments")
+	}
+}
+
+func TestTinyLFU(t *testing.T) {
+	f := NewTinyLFU(100)
+	f.Record(hash(1))
+	if n := f.Estimate(hash(1)); n != 1 {
+		t.Fatalf("expected first access to be held by the doorkeeper, got %d", n)
+	}
+	for i := 0; i < 4; i++ {
+		f.Record(hash(1))
+	}
+	if n := f.Estimate(hash(1)); n != 5 {
+		t.Fatalf("expected estimate 5, got %d", n)
+	}
+	f.Record(hash(2))
+	if f.Admit(hash(2), hash(1)) {
+		t.Fatal("expected infrequent candidate to be rejected")
+	}
+	if !f.Admit(hash(1), hash(2)) {
+		t.Fatal("expected frequent candidate to be admitted")
+	}
+	if !f.Admit(hash(3), hash(4)) {
+		t.Fatal("expected ties to be admitted")
+	}
+}
+
+func TestSpaceSaving(t *testing.T) {
+	s := NewSpaceSaving[int](10)
+	// Keys 0-4 are heavy hitters among a long tail of keys seen once.
+	tail := 1000
+	for i := 0; i < 10_000; i++ {
+		if i%2 == 0 {
+			s.Add(i / 2 % 5)
+		} else {
+			s.Add(tail)
+			tail++
+		}
+	}
+	top := s.Top(5)
+	if len(top) != 5 {
+		t.Fatalf("expected 5 counters, got %d", len(top))
+	}
+	for i, c := range top {
+		if c.Key < 0 || c.Key > 4 {
+			t.Fatalf("expected heavy hitters 0-4, got %+v at %d", c, i)
+		}
+		if c.Count-c.Err > 1000 || c.Count < 1000 {
+			t.Fatalf("expected count bounds around 1000, got %+v", c)
+		}
+	}
+	if n := len(s.Top(100)); n != 10 {
+		t.Fatalf("expected at most capacity counters, got %d", n)
+	}
+	s.Reset()
+	if n := len(s.Top(10)); n != 0 {
+		t.Fatalf("expected no counters after Reset, got %d", n)
+	}
+}
@@ -0,0 +1,118 @@
+package sketch
+
+import "sort"
+
+// SpaceSaving finds the most frequent keys of a stream in fixed space with the
+// Space-Saving algorithm of Metwally, Agrawal, and El Abbadi. It monitors up
+// to capacity keys; a key that is not monitored replaces the one with the
+// lowest count and inherits that count as its error. Any key whose true count
+// exceeds the stream length divided by capacity is guaranteed to be
+// monitored, and a monitored key's count overestimates its true count by at
+// most its error.
+type SpaceSaving[K comparable] struct {
+	// heap is a min-heap of the monitored keys by count, and pos the index of
+	// each key in it.
+	heap []Counter[K]
+	pos  map[K]int
+	cap  int
+}
+
+// Counter is a key monitored by a SpaceSaving sketch.
+type Counter[K comparable] struct {
+	Key   K
+	Count uint64
+	Err   uint64
+}
+
+// NewSpaceSaving returns a sketch monitoring up to capacity keys.
+func NewSpaceSaving[K comparable](capacity int) *SpaceSaving[K] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &SpaceSaving[K]{
+		heap: make([]Counter[K], 0, capacity),
+		pos:  make(map[K]int, capacity),
+		cap:  capacity,
+	}
+}
+
+// Add counts one occurrence of key.
+func (s *SpaceSaving[K]) Add(key K) {
+	if i, ok := s.pos[key]; ok {
+		s.heap[i].Count++
+		s.down(i)
+		return
+	}
+	if len(s.heap) < s.cap {
+		s.heap = append(s.heap, Counter[K]{Key: key, Count: 1})
+		s.pos[key] = len(s.heap) - 1
+		s.up(len(s.heap) - 1)
+		return
+	}
+	// Replace the minimum, which is at the root.
+	min := s.heap[0]
+	delete(s.pos, min.Key)
+	s.heap[0] = Counter[K]{Key: key, Count: min.Count + 1, Err: min.Count}
+	s.pos[key] = 0
+	s.down(0)
+}
+
+// Top returns up to n monitored keys with the highest counts, highest first.
+func (s *SpaceSaving[K]) Top(n int) []Counter[K] {
+	top := append([]Counter[K]{}, s.heap...)
+	SortCounters(top)
+	if n < len(top) {
+		top = top[:n]
+	}
+	return top
+}
+
+// Reset forgets all keys.
+func (s *SpaceSaving[K]) Reset() {
+	s.heap = s.heap[:0]
+	s.pos = make(map[K]int, s.cap)
+}
+
+// SortCounters sorts counters by count, highest first, and then by lowest
+// error.
+func SortCounters[K comparable](c []Counter[K]) {
+	sort.Slice(c, func(i, j int) bool {
+		if c[i].Count != c[j].Count {
+			return c[i].Count > c[j].Count
+		}
+		return c[i].Err < c[j].Err
+	})
+}
+
+func (s *SpaceSaving[K]) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if s.heap[parent].Count <= s.heap[i].Count {
+			return
+		}
+		s.swap(i, parent)
+		i = parent
+	}
+}
+
+func (s *SpaceSaving[K]) down(i int) {
+	for {
+		least := i
+		for _, child := range [2]int{2*i + 1, 2*i + 2} {
+			if child < len(s.heap) && s.heap[child].Count < s.heap[least].Count {
+				least = child
+			}
+		}
+		if least == i {
+			return
+		}
+		s.swap(i, least)
+		i = least
+	}
+}
+
+func (s *SpaceSaving[K]) swap(i, j int) {
+	s.heap[i], s.heap[j] = s.heap[j], s.heap[i]
+	s.pos[s.heap[i].Key] = i
+	s.pos[s.heap[j].Key] = j
+}
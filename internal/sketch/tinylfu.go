@@ -0,0 +1,42 @@
+package sketch
+
+// TinyLFU combines a Doorkeeper and a CountMin into the frequency estimator of
+// the TinyLFU admission policy: a key's first occurrence is recorded only in
+// the doorkeeper, and later ones in the sketch.
+type TinyLFU struct {
+	door   *Doorkeeper
+	counts *CountMin
+}
+
+// NewTinyLFU returns an estimator sized for a cache of about size entries.
+func NewTinyLFU(size int) *TinyLFU {
+	return &TinyLFU{
+		door:   NewDoorkeeper(size),
+		counts: NewCountMin(size),
+	}
+}
+
+// Record counts one access to the key with hash h.
+func (t *TinyLFU) Record(h uint64) {
+	if !t.door.Insert(h) {
+		return
+	}
+	if t.counts.Increment(h) {
+		t.door.Reset()
+	}
+}
+
+// Estimate returns the estimated access frequency of the key with hash h.
+func (t *TinyLFU) Estimate(h uint64) int {
+	n := t.counts.Estimate(h)
+	if t.door.Contains(h) {
+		n++
+	}
+	return n
+}
+
+// Admit reports whether a candidate should replace a victim: the candidate is
+// rejected only if its estimated frequency is lower than the victim's.
+func (t *TinyLFU) Admit(candidate, victim uint64) bool {
+	return t.Estimate(candidate) >= t.Estimate(victim)
+}
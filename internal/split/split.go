@@ -0,0 +1,48 @@
+// Package split divides a cache's capacity across shards, for the sharded
+// caches in this module, so they split it the same way and are comparable in
+// benchmarks.
+package split
+
+// MinKeysPerShard avoids splitting a tiny total capacity across many shards:
+// with one key per shard, distinct keys can collide on the same shard and
+// evict each other immediately. Larger caches still use every shard
+// requested.
+const MinKeysPerShard = 64
+
+// Shards returns the number of shards to use for a cache of size entries when
+// up to n are requested: n, reduced so each shard holds at least
+// MinKeysPerShard entries on average, but never less than 1.
+func Shards(size, n int) int {
+	if limit := size / MinKeysPerShard; n > limit {
+		n = limit
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// Capacity returns the capacity of each of n shards sharing size. A single
+// shard gets exactly size. Otherwise each gets ceil(size/n) plus slack:
+// hashing is not perfectly uniform, so a tight split whose sum is size can
+// overflow one shard and evict keys that others still have room for. The
+// slack is a quarter of each share, from 512 to 2048 entries, so the shards
+// may hold somewhat more than size entries in total. If weighted is true,
+// size is a cost budget, which has no natural unit, so the slack is an eighth
+// of each share instead.
+func Capacity(size, n int, weighted bool) int {
+	if n <= 1 || size == 0 {
+		return size
+	}
+	base := (size + n - 1) / n
+	if weighted {
+		return base + base/8
+	}
+	slack := base / 4
+	if slack < 512 {
+		slack = 512
+	} else if slack > 2048 {
+		slack = 2048
+	}
+	return base + slack
+}
@@ -0,0 +1,34 @@
+package split
+
+import "testing"
+
+func TestShards(t *testing.T) {
+	for _, tt := range []struct{ size, n, want int }{
+		{0, 16, 1},
+		{63, 16, 1},
+		{128, 16, 2},
+		{1 << 20, 16, 16},
+	} {
+		if got := Shards(tt.size, tt.n); got != tt.want {
+			t.Errorf("Shards(%d, %d) = %d, want %d", tt.size, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	for _, tt := range []struct {
+		size, n  int
+		weighted bool
+		want     int
+	}{
+		{0, 4, false, 0},
+		{1000, 1, false, 1000},
+		{1000, 4, false, 250 + 512},
+		{1 << 20, 16, false, 1<<16 + 2048},
+		{1 << 20, 16, true, 1<<16 + 1<<13},
+	} {
+		if got := Capacity(tt.size, tt.n, tt.weighted); got != tt.want {
+			t.Errorf("Capacity(%d, %d, %v) = %d, want %d", tt.size, tt.n, tt.weighted, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package lfu
+
+import (
+	"testing"
+)
+
+// TestAdmissionResistsScan interleaves a working set as large as the cache
+// with scans of keys that are used once. Without admission, scan keys displace
+// working set keys that have not yet been read since they were added; with it,
+// the sketch remembers the working set's misses and rejects the scan keys.
+func TestAdmissionResistsScan(t *testing.T) {
+	hitRatio := func(c *Cache[int, int]) float64 {
+		hits, total := 0, 0
+		scan := 1000
+		for round := 0; round < 200; round++ {
+			for k := 0; k < 100; k++ {
+				total++
+				if _, ok := c.Get(k); ok {
+					hits++
+				} else {
+					c.Set(k, k)
+				}
+			}
+			for i := 0; i < 100; i++ {
+				if _, ok := c.Get(scan); !ok {
+					c.Set(scan, scan)
+				}
+				scan++
+			}
+		}
+		return float64(hits) / float64(total)
+	}
+
+	var rejected int
+	c := NewWithOptions[int, int](100, WithAdmission(), WithOnEvict(func(k, v int, r EvictReason) {
+		if r == EvictRejected {
+			rejected++
+		}
+	}))
+	plain, admitted := hitRatio(New[int, int](100)), hitRatio(c)
+	if admitted < 0.9 || admitted < plain+0.1 {
+		t.Fatalf("expected admission to protect the working set: hit ratio %.3f with admission, %.3f without", admitted, plain)
+	}
+	if st := c.Stats(); st.Rejections == 0 || int(st.Rejections) != rejected {
+		t.Fatalf("expected rejections to be counted and reported, got %d counted, %d reported", st.Rejections, rejected)
+	}
+}
+
+func TestAdmissionAdmitsWhileNotFull(t *testing.T) {
+	c := NewWithOptions[int, int](4096, WithShards(4), WithAdmission())
+	for i := 0; i < 4096; i++ {
+		c.Set(i, i)
+	}
+	if c.Len() != 4096 {
+		t.Fatalf("expected admission to admit freely while there is room, Len %d", c.Len())
+	}
+}
+
+func TestAdmissionSkipsOverwrites(t *testing.T) {
+	var reasons []EvictReason
+	c := NewWithOptions[int, int](10, WithCostCapacity(), WithAdmission(), WithSeed(1), WithOnEvict(func(k, v int, r EvictReason) {
+		reasons = append(reasons, r)
+	}))
+	for k := 0; k < 10; k++ {
+		c.Set(k, k)
+	}
+	for i := 0; i < 100; i++ {
+		for k := 1; k < 10; k++ {
+			c.Get(k)
+		}
+	}
+	// Growing key 0's cost must evict another entry, but key 0 is already in
+	// the cache, so the sketch does not get to reject it.
+	c.SetWithCost(0, 0, 2)
+	if _, ok := c.Peek(0); !ok || c.Len() != 9 || c.Cost() != 10 {
+		t.Fatalf("expected key 0 to stay with cost 2 among 9 entries, reasons %v", reasons)
+	}
+	if len(reasons) != 2 || reasons[0] != EvictReplaced || reasons[1] != EvictCapacity {
+		t.Fatalf("expected the old value replaced and one entry evicted, got %v", reasons)
+	}
+}
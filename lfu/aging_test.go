@@ -0,0 +1,63 @@
+package lfu
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAgingDemotesOneBucket(t *testing.T) {
+	c := NewWithOptions[int, int](10, WithAging(1000))
+	for i := 0; i < 4; i++ {
+		c.Set(i, i)
+	}
+	s := c.shards[0]
+	s.mu.Lock()
+	// Key i starts in bucket i.
+	for i := int8(1); i < defaultNumBuckets; i++ {
+		for j := int8(0); j < i; j++ {
+			s.promote(j, int(i))
+		}
+	}
+	s.age()
+	s.mu.Unlock()
+
+	if got := c.Stats().Buckets; !reflect.DeepEqual(got, []int{2, 1, 1, 0}) {
+		t.Fatalf("expected one bucket of demotion, got %v", got)
+	}
+	for k := 0; k < 4; k++ {
+		if v, ok := c.Peek(k); !ok || v != k {
+			t.Fatalf("expected key %d to survive aging, got %d, %v", k, v, ok)
+		}
+	}
+}
+
+// TestAgingAdaptsToShiftingPopularity replays a workload whose hot set changes
+// halfway through. Without aging, the first hot set stays in the high buckets
+// and the second competes for the few remaining slots.
+func TestAgingAdaptsToShiftingPopularity(t *testing.T) {
+	hitRatio := func(c *Cache[int, int]) float64 {
+		for i := 0; i < 80; i++ {
+			c.Set(i, i)
+			for j := 0; j < 300; j++ {
+				c.Get(i)
+			}
+		}
+		before := c.Stats()
+		for round := 0; round < 1000; round++ {
+			for k := 1000; k < 1080; k++ {
+				if _, ok := c.Get(k); !ok {
+					c.Set(k, k)
+				}
+			}
+		}
+		after := c.Stats()
+		hits := after.Hits - before.Hits
+		return float64(hits) / float64(hits+after.Misses-before.Misses)
+	}
+
+	plain := hitRatio(New[int, int](100))
+	aged := hitRatio(NewWithOptions[int, int](100, WithAging(1000)))
+	if aged < 0.9 || aged < plain+0.3 {
+		t.Fatalf("expected aging to adapt to the new hot set: hit ratio %.3f with aging, %.3f without", aged, plain)
+	}
+}
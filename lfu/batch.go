@@ -0,0 +1,107 @@
+package lfu
+
+// GetMany looks up each of keys, as Get does, and returns the values and
+// whether each was found, in the same order as keys. Keys are grouped by
+// shard so each shard's mutex is taken once per call rather than once per
+// key.
+func (c *Cache[K, V]) GetMany(keys []K) (values []V, ok []bool) {
+	values = make([]V, len(keys))
+	ok = make([]bool, len(keys))
+	c.eachShard(keys, func(s *lfuShard[K, V], at []int) {
+		for _, i := range at {
+			values[i], ok[i] = s.getLocked(keys[i])
+		}
+	})
+	return values, ok
+}
+
+// SetMany adds or updates each of keys with the value at the same index in
+// values, as Set does, taking each shard's mutex once per call. When a key
+// appears more than once, the last value wins. SetMany panics if keys and
+// values have different lengths.
+func (c *Cache[K, V]) SetMany(keys []K, values []V) {
+	if len(keys) != len(values) {
+		panic("lfu: SetMany keys and values must have the same length")
+	}
+	var costs []int64
+	if c.weighted {
+		// Weighers run without any lock held, as they do for Set.
+		costs = make([]int64, len(keys))
+		for i, k := range keys {
+			costs[i] = c.shards[0].weigh(k, values[i])
+		}
+	}
+	c.eachShard(keys, func(s *lfuShard[K, V], at []int) {
+		for _, i := range at {
+			cost := int64(1)
+			if costs != nil {
+				cost = costs[i]
+			}
+			s.setLocked(keys[i], values[i], s.ttl, cost)
+		}
+	})
+}
+
+// RemoveMany deletes each of keys, as Remove does, taking each shard's mutex
+// once per call. It returns the number of keys that were present.
+func (c *Cache[K, V]) RemoveMany(keys []K) int {
+	n := 0
+	c.eachShard(keys, func(s *lfuShard[K, V], at []int) {
+		for _, i := range at {
+			if s.removeLocked(keys[i]) {
+				n++
+			}
+		}
+	})
+	return n
+}
+
+// eachShard calls fn once for each shard that owns any of keys, with the
+// shard's mutex held and the indexes in keys of the keys it owns, in
+// increasing order. Each key is hashed once.
+func (c *Cache[K, V]) eachShard(keys []K, fn func(s *lfuShard[K, V], at []int)) {
+	if len(keys) == 0 {
+		return
+	}
+	at := make([]int, len(keys))
+	if len(c.shards) == 1 {
+		for i := range at {
+			at[i] = i
+		}
+		s := c.shards[0]
+		s.mu.Lock()
+		fn(s, at)
+		s.unlock()
+		return
+	}
+
+	// Counting sort of key indexes by shard: ends[j] is the end of shard j's
+	// run in at.
+	shard := make([]int, len(keys))
+	ends := make([]int, len(c.shards))
+	for i, k := range keys {
+		shard[i] = c.shardIndex(k)
+		ends[shard[i]]++
+	}
+	for j := 1; j < len(ends); j++ {
+		ends[j] += ends[j-1]
+	}
+	for i := len(keys) - 1; i >= 0; i-- {
+		j := shard[i]
+		ends[j]--
+		at[ends[j]] = i
+	}
+	// ends[j] is now the start of shard j's run.
+	for j, s := range c.shards {
+		end := len(keys)
+		if j+1 < len(ends) {
+			end = ends[j+1]
+		}
+		if ends[j] == end {
+			continue
+		}
+		s.mu.Lock()
+		fn(s, at[ends[j]:end])
+		s.unlock()
+	}
+}
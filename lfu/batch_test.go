@@ -0,0 +1,59 @@
+package lfu
+
+import "testing"
+
+func TestBatch(t *testing.T) {
+	for _, shards := range []int{1, 16} {
+		c := NewSharded[int, int](4096, shards)
+		keys := make([]int, 200)
+		values := make([]int, len(keys))
+		for i := range keys {
+			keys[i] = i * 7
+			values[i] = i
+		}
+		c.SetMany(keys[:100], values[:100])
+		if c.Len() != 100 {
+			t.Fatalf("%d shards: expected 100 entries, got %d", shards, c.Len())
+		}
+
+		got, ok := c.GetMany(keys)
+		for i := range keys {
+			if hit := i < 100; ok[i] != hit || (hit && got[i] != i) {
+				t.Fatalf("%d shards: key %d: got %d, %v", shards, keys[i], got[i], ok[i])
+			}
+		}
+		if st := c.Stats(); st.Hits != 100 || st.Misses != 100 {
+			t.Fatalf("%d shards: expected 100 hits and 100 misses, got %d and %d", shards, st.Hits, st.Misses)
+		}
+
+		// Duplicate keys are applied in order.
+		c.SetMany([]int{1, 1}, []int{10, 11})
+		if v, _ := c.Peek(1); v != 11 {
+			t.Fatalf("%d shards: expected last value to win, got %d", shards, v)
+		}
+
+		if n := c.RemoveMany(append(keys[50:150], 1)); n != 51 {
+			t.Fatalf("%d shards: expected 51 keys removed, got %d", shards, n)
+		}
+		if c.Len() != 50 {
+			t.Fatalf("%d shards: expected 50 entries, got %d", shards, c.Len())
+		}
+	}
+}
+
+func TestSetManyLengthMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected SetMany with mismatched lengths to panic")
+		}
+	}()
+	New[int, int](10).SetMany([]int{1, 2}, []int{1})
+}
+
+func TestSetManyWeigher(t *testing.T) {
+	c := NewWithOptions[string, string](10, WithWeigher(func(k, v string) int64 { return int64(len(v)) }))
+	c.SetMany([]string{"a", "b"}, []string{"xxx", "yyyy"})
+	if c.Cost() != 7 {
+		t.Fatalf("expected cost 7, got %d", c.Cost())
+	}
+}
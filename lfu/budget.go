@@ -0,0 +1,86 @@
+package lfu
+
+import "sync/atomic"
+
+// budget is the capacity shared by the shards of a cache with
+// WithStrictCapacity. Each shard reserves an entry's cost (1 for a cache that
+// counts entries) before adding it and releases it when the entry leaves, so
+// the total never exceeds limit.
+type budget struct {
+	limit atomic.Int64
+	used  atomic.Int64
+}
+
+// reserve takes cost from the budget and reports whether it fit.
+func (b *budget) reserve(cost int64) bool {
+	for {
+		used := b.used.Load()
+		if used+cost > b.limit.Load() {
+			return false
+		}
+		if b.used.CompareAndSwap(used, used+cost) {
+			return true
+		}
+	}
+}
+
+func (b *budget) release(cost int64) {
+	b.used.Add(-cost)
+}
+
+// charge returns what an entry of the given cost takes from the shared
+// budget.
+func (s *lfuShard[K, V]) charge(cost int64) int64 {
+	if s.costs == nil {
+		return 1
+	}
+	return cost
+}
+
+// makeRoom evicts an entry to free capacity, from this shard if it has any and
+// otherwise, in a strict cache, from another shard (see steal). It reports
+// whether an entry was evicted.
+func (s *lfuShard[K, V]) makeRoom() bool {
+	return s.evict() || s.budget != nil && s.steal()
+}
+
+// steal evicts the victim of another shard, for a strict cache whose budget is
+// held by other shards. This shard's mutex is held, so to avoid deadlock the
+// others are only try-locked, in turn; steal returns false if none could be
+// locked or all are empty. Callbacks for the stolen entry are delivered with
+// this shard's, once its mutex is released.
+func (s *lfuShard[K, V]) steal() bool {
+	for range s.peers {
+		s.next = (s.next + 1) % len(s.peers)
+		p := s.peers[s.next]
+		if p == s || !p.mu.TryLock() {
+			continue
+		}
+		ok := p.evict()
+		s.pending = append(s.pending, p.pending...)
+		p.pending = nil
+		p.mu.Unlock()
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// shrink sets the shard's capacity for Resize of a strict cache whose budget
+// had used of its new limit taken. If used is over the limit, the shard evicts
+// its low-frequency entries until it holds no more than its proportional share
+// of the limit, so a shard holding most of the cache frees most of the room.
+func (s *lfuShard[K, V]) shrink(limit, used int64) {
+	s.mu.Lock()
+	s.cap = int(limit)
+	if used > limit {
+		share := int64(float64(s.costLocked()) * float64(limit) / float64(used))
+		for len(s.index) > 0 && s.costLocked() > share {
+			if !s.evict() {
+				break
+			}
+		}
+	}
+	s.unlock()
+}
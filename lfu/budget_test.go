@@ -0,0 +1,121 @@
+package lfu
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestStrictCapacityConcurrent(t *testing.T) {
+	const size = 1000
+	c := NewWithOptions[int, int](size, WithShards(16), WithStrictCapacity())
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(g)))
+			for i := 0; i < 20_000; i++ {
+				k := r.Intn(10 * size)
+				switch r.Intn(10) {
+				case 0:
+					c.Remove(k)
+				case 1:
+					c.Get(k)
+				default:
+					c.Set(k, k)
+				}
+				if used := c.budget.used.Load(); used > size {
+					t.Errorf("budget exceeded: %d", used)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if n := c.Len(); n > size || int64(n) != c.budget.used.Load() {
+		t.Fatalf("expected at most %d entries matching the budget, got %d entries and %d used", size, n, c.budget.used.Load())
+	}
+}
+
+func TestStrictCapacitySteals(t *testing.T) {
+	const size = 128
+	c := NewWithOptions[int, int](size, WithShards(2), WithStrictCapacity())
+	var other int
+	added := 0
+	for k := 0; added < size || other == 0; k++ {
+		if c.shardIndex(k) == 1 {
+			if other == 0 {
+				other = k
+			}
+			continue
+		}
+		c.Set(k, k)
+		added++
+	}
+	if n := c.Len(); n != size {
+		t.Fatalf("expected shard 0 to take the whole budget, got %d entries", n)
+	}
+	c.Set(other, other)
+	if _, ok := c.Peek(other); !ok {
+		t.Fatal("expected the empty shard to steal room for a new entry")
+	}
+	if h := c.Histogram(); h[0][0] != size-1 || c.Len() != size {
+		t.Fatalf("expected one entry stolen from shard 0, got %v", h)
+	}
+}
+
+func TestStrictCapacityCostAndResize(t *testing.T) {
+	c := NewWithOptions[int, int](1000, WithShards(8), WithStrictCapacity(), WithWeigher(func(k, v int) int64 { return int64(v) }))
+	for k := 0; k < 1000; k++ {
+		c.Set(k, k%10)
+		if cost := c.Cost(); cost > 1000 {
+			t.Fatalf("cost %d exceeds budget", cost)
+		}
+	}
+	c.Resize(100)
+	if cost := c.Cost(); cost > 100 {
+		t.Fatalf("cost %d exceeds resized budget", cost)
+	}
+	for k := 0; k < 1000; k++ {
+		c.Set(k, k%10)
+	}
+	if cost := c.Cost(); cost > 100 || cost != c.budget.used.Load() {
+		t.Fatalf("expected cost at most 100 matching the budget, got %d and %d used", cost, c.budget.used.Load())
+	}
+	c.Clear()
+	if used := c.budget.used.Load(); used != 0 {
+		t.Fatalf("expected Clear to release the budget, got %d used", used)
+	}
+}
+
+func TestStrictCapacityResizeSkewed(t *testing.T) {
+	const size = 1024
+	c := NewWithOptions[int, int](size, WithShards(4), WithStrictCapacity())
+	// Every key lands in shard 0, which holds nearly the whole budget.
+	var keys []int
+	for k := 0; len(keys) < 1000; k++ {
+		if c.shardIndex(k) == 0 {
+			keys = append(keys, k)
+			c.Set(k, k)
+		}
+	}
+	c.Resize(2 * size)
+	if n := c.Len(); n != 1000 {
+		t.Fatalf("expected growing to keep all 1000 entries, got %d", n)
+	}
+	c.Resize(size)
+	if n := c.Len(); n != 1000 {
+		t.Fatalf("expected a resize to a size that still fits to keep all 1000 entries, got %d", n)
+	}
+	c.Resize(800)
+	if n := c.Len(); n != 800 {
+		t.Fatalf("expected shrinking to evict only down to 800 entries, got %d", n)
+	}
+	for _, k := range keys {
+		c.Set(k, k)
+	}
+	if n := c.Len(); n != 800 || c.budget.used.Load() != 800 {
+		t.Fatalf("expected 800 entries matching the budget, got %d and %d used", n, c.budget.used.Load())
+	}
+}
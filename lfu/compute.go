@@ -0,0 +1,202 @@
+package lfu
+
+// ComputeAction tells Compute what to do with an entry.
+type ComputeAction uint8
+
+const (
+	// ComputeKeep leaves the entry, or its absence, unchanged.
+	ComputeKeep ComputeAction = iota
+	// ComputeReplace stores the value returned by the compute function.
+	ComputeReplace
+	// ComputeDelete removes the entry if it is present.
+	ComputeDelete
+)
+
+// SetIfAbsent adds key with value, as Set does, unless key is already present,
+// in which case the existing entry is left untouched and its frequency is not
+// updated. It reports whether key was absent. As with Set, a new entry may not
+// be retained if it does not fit or the admission policy rejects it.
+func (c *Cache[K, V]) SetIfAbsent(key K, value V) bool {
+	s := c.shards[c.shardIndex(key)]
+	cost := s.weigh(key, value)
+	s.mu.Lock()
+	defer s.unlock()
+	if i, ok := s.index[key]; ok && !s.expiredLocked(i, key) {
+		return false
+	}
+	s.setLocked(key, value, s.ttl, cost)
+	return true
+}
+
+// Update replaces the value of key if it is present and reports whether it
+// was. Unlike Set, the key keeps its frequency bucket and deadline, so keys
+// that are refreshed often are not demoted to the lowest bucket; and unlike
+// Get, Update does not count as an access. See WithPreserveFrequency to make
+// Set behave this way.
+func (c *Cache[K, V]) Update(key K, value V) bool {
+	s := c.shards[c.shardIndex(key)]
+	cost := s.weigh(key, value)
+	s.mu.Lock()
+	defer s.unlock()
+	i, ok := s.index[key]
+	if !ok || s.expiredLocked(i, key) {
+		return false
+	}
+	s.stats.Sets++
+	s.stats.Overwrites++
+	s.replaceLocked(i, key, value, cost)
+	return true
+}
+
+// Swap sets key to value exactly as Set does and returns the previous value,
+// if any.
+func (c *Cache[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	s := c.shards[c.shardIndex(key)]
+	cost := s.weigh(key, value)
+	s.mu.Lock()
+	defer s.unlock()
+	if i, ok := s.index[key]; ok && !s.expiredLocked(i, key) {
+		previous, loaded = s.buckets[i][key], true
+	}
+	s.setLocked(key, value, s.ttl, cost)
+	return previous, loaded
+}
+
+// CompareAndSwap sets key to new if it is present with a value equal to old,
+// and reports whether it did. See CompareAndSwapFunc for how the swap affects
+// the entry.
+func CompareAndSwap[K comparable, V comparable](c *Cache[K, V], key K, old, new V) bool {
+	return c.CompareAndSwapFunc(key, old, new, func(a, b V) bool { return a == b })
+}
+
+// CompareAndSwapFunc sets key to new if it is present with a value that equal
+// reports equal to old, and reports whether it did. equal is called with the
+// shard's mutex held and must not call the cache.
+//
+// Unlike Set, a successful swap replaces the value in place: the key keeps
+// its frequency bucket and deadline. Whether or not the swap succeeds, the
+// call counts as an access to key, like Get, so a present key may be promoted.
+func (c *Cache[K, V]) CompareAndSwapFunc(key K, old, new V, equal func(a, b V) bool) bool {
+	s := c.shards[c.shardIndex(key)]
+	cost := s.weigh(key, new)
+	s.mu.Lock()
+	defer s.unlock()
+	i, ok := s.lookupLocked(key)
+	if !ok {
+		return false
+	}
+	s.touch(i, key)
+	i = s.index[key]
+	if !equal(s.buckets[i][key], old) {
+		return false
+	}
+	s.stats.Sets++
+	s.stats.Overwrites++
+	s.replaceLocked(i, key, new, cost)
+	return true
+}
+
+// Compute atomically reads, modifies, or deletes key. fn is called with the
+// current value and whether key is present, and its returned action decides
+// what happens:
+//
+//   - ComputeKeep leaves the cache unchanged.
+//   - ComputeReplace stores the returned value. A present key keeps its
+//     frequency bucket and deadline, as with CompareAndSwapFunc; an absent key
+//     is added as if by Set, entering the lowest bucket with the cache's
+//     default TTL.
+//   - ComputeDelete removes key, as Remove does.
+//
+// Compute counts as an access to a present key, like Get, unless fn deletes
+// it. It returns the key's value afterward and whether it is present.
+//
+// fn runs with the shard's mutex held, so operations on other keys in the
+// same shard wait for it, and it must not call the cache. In a cost-aware
+// cache the weigher is likewise called with the mutex held.
+func (c *Cache[K, V]) Compute(key K, fn func(old V, ok bool) (V, ComputeAction)) (value V, ok bool) {
+	s := c.shards[c.shardIndex(key)]
+	s.mu.Lock()
+	defer s.unlock()
+	i, ok := s.lookupLocked(key)
+	var old V
+	if ok {
+		old = s.buckets[i][key]
+	}
+	value, action := fn(old, ok)
+	switch action {
+	case ComputeReplace:
+		cost := s.weigh(key, value)
+		if !ok {
+			s.setLocked(key, value, s.ttl, cost)
+			break
+		}
+		s.touch(i, key)
+		s.stats.Sets++
+		s.stats.Overwrites++
+		s.replaceLocked(s.index[key], key, value, cost)
+	case ComputeDelete:
+		if ok {
+			s.drop(i, key, EvictRemoved)
+		}
+		var zero V
+		return zero, false
+	default:
+		if !ok {
+			var zero V
+			return zero, false
+		}
+		s.touch(i, key)
+		return old, true
+	}
+	if _, ok := s.index[key]; !ok {
+		var zero V
+		return zero, false
+	}
+	return value, true
+}
+
+// replaceLocked replaces the value of key, which must be live in bucket i,
+// keeping its bucket and deadline. The old value is reported to WithOnEvict
+// as replaced. In a cost-aware shard, other entries are evicted if the new
+// cost does not fit, and the entry is dropped if it exceeds the whole budget.
+func (s *lfuShard[K, V]) replaceLocked(i int8, key K, value V, cost int64) {
+	if s.costs == nil {
+		s.notify(key, s.buckets[i][key], EvictReplaced)
+		s.buckets[i][key] = value
+		return
+	}
+	if cost < 0 {
+		cost = 0
+	}
+	var deadline int64
+	if s.expiry != nil {
+		deadline = s.expiry[key]
+	}
+	added, count := s.added[key], s.counts[key]
+	// Take the entry out while making room so it cannot be its own victim.
+	s.drop(i, key, EvictReplaced)
+	if cost > int64(s.cap) {
+		s.stats.Evictions++
+		s.notify(key, value, EvictCapacity)
+		return
+	}
+	for s.full(cost) {
+		if !s.makeRoom() {
+			s.stats.Evictions++
+			s.notify(key, value, EvictCapacity)
+			return
+		}
+	}
+	s.insert(i, key, value)
+	if s.added != nil {
+		s.added[key] = added
+	}
+	if s.counts != nil && count > 0 {
+		s.counts[key] = count
+	}
+	if deadline != 0 {
+		s.expireAt(key, deadline)
+	}
+	s.costs[key] = cost
+	s.used += cost
+}
@@ -0,0 +1,126 @@
+package lfu
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSetIfAbsent(t *testing.T) {
+	c := New[int, int](10)
+	if !c.SetIfAbsent(1, 1) {
+		t.Fatal("expected absent key to be set")
+	}
+	if c.SetIfAbsent(1, 2) {
+		t.Fatal("expected present key not to be set")
+	}
+	if v, _ := c.Peek(1); v != 1 {
+		t.Fatalf("expected existing value to be kept, got %d", v)
+	}
+}
+
+func TestSwap(t *testing.T) {
+	c := New[int, int](10)
+	if _, loaded := c.Swap(1, 1); loaded {
+		t.Fatal("expected no previous value")
+	}
+	c.shards[0].promote(0, 1)
+	if prev, loaded := c.Swap(1, 2); !loaded || prev != 1 {
+		t.Fatalf("expected previous value 1, got %d, %v", prev, loaded)
+	}
+	if i := c.shards[0].index[1]; i != 0 {
+		t.Fatalf("expected Swap to reset the key to bucket 0, got %d", i)
+	}
+}
+
+func TestCompareAndSwapKeepsBucket(t *testing.T) {
+	c := NewWithOptions[int, int](10, WithPromotionCurve([]float64{0, 0, 0}))
+	c.Set(1, 1)
+	s := c.shards[0]
+	s.promote(0, 1)
+	s.promote(1, 1)
+
+	if CompareAndSwap(c, 1, 5, 6) {
+		t.Fatal("expected swap with wrong old value to fail")
+	}
+	if !CompareAndSwap(c, 1, 1, 2) {
+		t.Fatal("expected swap to succeed")
+	}
+	if v, _ := c.Peek(1); v != 2 {
+		t.Fatalf("expected swapped value 2, got %d", v)
+	}
+	if i := s.index[1]; i != 2 {
+		t.Fatalf("expected the key to stay in bucket 2, got %d", i)
+	}
+	if CompareAndSwap(c, 3, 0, 1) {
+		t.Fatal("expected swap of absent key to fail")
+	}
+}
+
+func TestComputeCounter(t *testing.T) {
+	c := NewSharded[string, int](1024, 4)
+	incr := func(old int, ok bool) (int, ComputeAction) {
+		return old + 1, ComputeReplace
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				c.Compute("n", incr)
+			}
+		}()
+	}
+	wg.Wait()
+	if v, ok := c.Peek("n"); !ok || v != 8000 {
+		t.Fatalf("expected 8000, got %d, %v", v, ok)
+	}
+}
+
+func TestComputeActions(t *testing.T) {
+	c := New[int, int](10)
+	keep := func(old int, ok bool) (int, ComputeAction) { return 99, ComputeKeep }
+	del := func(old int, ok bool) (int, ComputeAction) { return 0, ComputeDelete }
+
+	if _, ok := c.Compute(1, keep); ok || c.Len() != 0 {
+		t.Fatal("expected keep on an absent key not to add it")
+	}
+	c.Set(1, 1)
+	if v, ok := c.Compute(1, keep); !ok || v != 1 {
+		t.Fatalf("expected keep to return the current value, got %d, %v", v, ok)
+	}
+	if _, ok := c.Compute(1, del); ok {
+		t.Fatal("expected delete to report the key absent")
+	}
+	if _, ok := c.Peek(1); ok {
+		t.Fatal("expected key to be deleted")
+	}
+	if st := c.Stats(); st.Removals != 1 {
+		t.Fatalf("expected 1 removal, got %d", st.Removals)
+	}
+}
+
+func TestComputeCostGrows(t *testing.T) {
+	var reasons []EvictReason
+	c := NewWithOptions[int, string](10,
+		WithWeigher(func(k int, v string) int64 { return int64(len(v)) }),
+		WithOnEvict(func(k int, v string, r EvictReason) { reasons = append(reasons, r) }))
+	c.Set(1, "aaaa")
+	c.Set(2, "bbbb")
+	c.shards[0].promote(0, 1)
+	v, ok := c.Compute(1, func(old string, ok bool) (string, ComputeAction) {
+		return old + "aaaa", ComputeReplace
+	})
+	if !ok || v != "aaaaaaaa" {
+		t.Fatalf("expected grown value, got %q, %v", v, ok)
+	}
+	if _, ok := c.Peek(2); ok {
+		t.Fatal("expected the other entry to be evicted to make room")
+	}
+	if c.Cost() != 8 || c.shards[0].index[1] != 1 {
+		t.Fatalf("expected cost 8 with key 1 in bucket 1, got %d and bucket %d", c.Cost(), c.shards[0].index[1])
+	}
+	if len(reasons) != 2 || reasons[0] != EvictReplaced || reasons[1] != EvictCapacity {
+		t.Fatalf("unexpected eviction reasons %v", reasons)
+	}
+}
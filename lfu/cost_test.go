@@ -0,0 +1,70 @@
+package lfu
+
+import (
+	"testing"
+)
+
+func TestWeigherLimitsTotalCost(t *testing.T) {
+	c := NewWithOptions[int, []byte](100, WithWeigher(func(k int, v []byte) int64 {
+		return int64(len(v))
+	}))
+	for i := 0; i < 10; i++ {
+		c.Set(i, make([]byte, 30))
+		if c.Cost() > 100 {
+			t.Fatalf("expected cost at most 100, got %d", c.Cost())
+		}
+	}
+	if c.Len() != 3 {
+		t.Fatalf("expected 3 entries of cost 30, got %d", c.Len())
+	}
+	if _, ok := c.Get(9); !ok {
+		t.Fatal("expected most recent entry to be present")
+	}
+
+	// A larger entry evicts as many entries as it needs.
+	c.Set(100, make([]byte, 90))
+	if c.Len() != 1 || c.Cost() != 90 {
+		t.Fatalf("expected one entry of cost 90, got Len %d Cost %d", c.Len(), c.Cost())
+	}
+}
+
+func TestSetWithCost(t *testing.T) {
+	c := NewWithOptions[int, int](10, WithCostCapacity())
+	c.Set(1, 1)
+	c.SetWithCost(2, 2, 4)
+	if c.Cost() != 5 {
+		t.Fatalf("expected cost 5, got %d", c.Cost())
+	}
+
+	// Growing an existing entry keeps it and evicts others.
+	c.SetWithCost(2, 2, 10)
+	if _, ok := c.Get(2); !ok {
+		t.Fatal("expected overwritten entry to remain")
+	}
+	if c.Len() != 1 || c.Cost() != 10 {
+		t.Fatalf("expected one entry of cost 10, got Len %d Cost %d", c.Len(), c.Cost())
+	}
+
+	// An entry over budget is not retained and replaces the old value.
+	c.SetWithCost(2, 2, 11)
+	if _, ok := c.Get(2); ok {
+		t.Fatal("expected entry over budget not to be retained")
+	}
+	if c.Cost() != 0 {
+		t.Fatalf("expected cost 0, got %d", c.Cost())
+	}
+}
+
+func TestShardedCostBudget(t *testing.T) {
+	const budget = 1 << 20
+	c := NewWithOptions[int, int](budget, WithShards(8), WithCostCapacity())
+	for i := 0; i < 10_000; i++ {
+		c.SetWithCost(i, i, 1024)
+	}
+	if max := int64(budget + budget/8); c.Cost() > max {
+		t.Fatalf("expected cost at most %d, got %d", max, c.Cost())
+	}
+	if c.Cost() < budget/2 {
+		t.Fatalf("expected shards to use most of the budget, got %d", c.Cost())
+	}
+}
@@ -0,0 +1,104 @@
+package lfu
+
+import (
+	"testing"
+	"time"
+)
+
+type evictRecord struct {
+	key, value int
+	reason     EvictReason
+}
+
+func TestOnEvict(t *testing.T) {
+	advance := fakeClock(t)
+	var got []evictRecord
+	c := NewWithOptions[int, int](2, WithOnEvict(func(k, v int, r EvictReason) {
+		got = append(got, evictRecord{k, v, r})
+	}))
+
+	c.Set(1, 1)
+	c.Set(1, 10)
+	c.Remove(1)
+	c.Set(2, 2)
+	c.Set(3, 3)
+	c.Set(4, 4) // evicts 2 or 3
+	c.SetWithTTL(5, 5, time.Second)
+	advance(time.Second)
+	c.Get(5)
+	c.Clear()
+
+	want := []EvictReason{EvictReplaced, EvictRemoved, EvictCapacity, EvictCapacity, EvictExpired, EvictCleared}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d callbacks, got %v", len(want), got)
+	}
+	for i, r := range want {
+		if got[i].reason != r {
+			t.Fatalf("callback %d: expected reason %v, got %v", i, r, got[i].reason)
+		}
+	}
+	if got[0] != (evictRecord{1, 1, EvictReplaced}) {
+		t.Fatalf("expected old value of replaced entry, got %v", got[0])
+	}
+	if got[1] != (evictRecord{1, 10, EvictRemoved}) {
+		t.Fatalf("expected removed entry, got %v", got[1])
+	}
+	if got[4] != (evictRecord{5, 5, EvictExpired}) {
+		t.Fatalf("expected expired entry, got %v", got[4])
+	}
+}
+
+func TestOnEvictCanCallCache(t *testing.T) {
+	var c *Cache[int, int]
+	readded := false
+	c = NewWithOptions[int, int](2, WithOnEvict(func(k, v int, r EvictReason) {
+		if _, ok := c.Peek(k); ok {
+			t.Errorf("expected evicted key %d to be gone when callback runs", k)
+		}
+		if !readded {
+			readded = true
+			c.Set(-k, v)
+		}
+	}))
+	c.Set(1, 1)
+	c.Remove(1)
+	if v, ok := c.Get(-1); !ok || v != 1 {
+		t.Fatalf("expected callback to re-add removed entry, got %d, %v", v, ok)
+	}
+}
+
+func TestOnEvictTypeMismatchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for mismatched callback type")
+		}
+	}()
+	NewWithOptions[int, int](1, WithOnEvict(func(k string, v int, r EvictReason) {}))
+}
+
+func TestVictimSampleSparesNewKeys(t *testing.T) {
+	c := NewWithOptions[int, int](100, WithVictimSample(8), WithSeed(1))
+	for i := 0; i < 100; i++ {
+		c.Set(i, i)
+	}
+	// Each new key displaces one of the keys added before it, never itself or
+	// the key added just before it.
+	for i := 100; i < 1000; i++ {
+		c.Set(i, i)
+		if _, ok := c.Peek(i); !ok {
+			t.Fatalf("expected new key %d to be retained", i)
+		}
+		if _, ok := c.Peek(i - 1); !ok {
+			t.Fatalf("expected recently added key %d to survive", i-1)
+		}
+	}
+
+	// Keys in higher buckets are still protected.
+	c.Get(999)
+	for i := 1000; i < 2000; i++ {
+		c.Set(i, i)
+	}
+	if _, ok := c.Peek(999); !ok {
+		t.Fatal("expected promoted key to survive")
+	}
+}
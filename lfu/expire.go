@@ -0,0 +1,98 @@
+package lfu
+
+// deadline is an entry of a deadlineHeap: the unix nanosecond at which key
+// expires.
+type deadline[K comparable] struct {
+	at  int64
+	key K
+}
+
+// deadlineHeap is a min-heap of deadlines, earliest first, so a shard finds
+// its expired entries without scanning the rest. Entries are not removed when
+// a key is deleted or given a new deadline; a popped entry whose deadline no
+// longer matches the shard's expiry map is stale and is skipped.
+type deadlineHeap[K comparable] []deadline[K]
+
+func (h *deadlineHeap[K]) push(d deadline[K]) {
+	*h = append(*h, d)
+	s := *h
+	for i := len(s) - 1; i > 0; {
+		parent := (i - 1) / 2
+		if s[parent].at <= s[i].at {
+			break
+		}
+		s[parent], s[i] = s[i], s[parent]
+		i = parent
+	}
+}
+
+func (h *deadlineHeap[K]) pop() deadline[K] {
+	s := *h
+	d := s[0]
+	n := len(s) - 1
+	s[0] = s[n]
+	s[n] = deadline[K]{}
+	s = s[:n]
+	*h = s
+	h.down(0)
+	return d
+}
+
+func (h deadlineHeap[K]) down(i int) {
+	for {
+		min, left := i, 2*i+1
+		if left < len(h) && h[left].at < h[min].at {
+			min = left
+		}
+		if right := left + 1; right < len(h) && h[right].at < h[min].at {
+			min = right
+		}
+		if min == i {
+			return
+		}
+		h[i], h[min] = h[min], h[i]
+		i = min
+	}
+}
+
+// expireAt sets key's deadline to at, in unix nanoseconds.
+func (s *lfuShard[K, V]) expireAt(key K, at int64) {
+	if s.expiry == nil {
+		s.expiry = map[K]int64{}
+	}
+	s.expiry[key] = at
+	s.deadlines.push(deadline[K]{at, key})
+	// Rebuild the heap from expiry once stale entries make up most of it,
+	// which keeps its size proportional to the number of deadlines.
+	if len(s.deadlines) > 2*len(s.expiry)+16 {
+		s.deadlines = s.deadlines[:0]
+		for k, at := range s.expiry {
+			s.deadlines = append(s.deadlines, deadline[K]{at, k})
+		}
+		for i := len(s.deadlines)/2 - 1; i >= 0; i-- {
+			s.deadlines.down(i)
+		}
+	}
+}
+
+// reapExpired deletes every entry whose deadline is at or before now and
+// returns the number deleted. It pops only expired and stale deadlines, so it
+// costs little when nothing has expired.
+func (s *lfuShard[K, V]) reapExpired(now int64) int {
+	reaped := 0
+	for len(s.deadlines) > 0 && s.deadlines[0].at <= now {
+		d := s.deadlines.pop()
+		if at, ok := s.expiry[d.key]; ok && at == d.at {
+			s.drop(s.index[d.key], d.key, EvictExpired)
+			reaped++
+		}
+	}
+	return reaped
+}
+
+// reapDue reclaims expired entries ahead of an eviction, so that expired
+// entries are always evicted before live ones. It reports whether any were
+// deleted.
+func (s *lfuShard[K, V]) reapDue() bool {
+	return len(s.deadlines) > 0 && s.reapExpired(nowNano()) > 0
+}
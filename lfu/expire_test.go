@@ -0,0 +1,139 @@
+package lfu
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock replaces nowNano for the duration of a test and returns a function
+// that advances it.
+func fakeClock(t *testing.T) func(d time.Duration) {
+	t.Helper()
+	orig := nowNano
+	now := time.Now().UnixNano()
+	nowNano = func() int64 { return now }
+	t.Cleanup(func() { nowNano = orig })
+	return func(d time.Duration) { now += int64(d) }
+}
+
+func TestDefaultTTL(t *testing.T) {
+	advance := fakeClock(t)
+	c := NewWithOptions[int, int](10, WithTTL(time.Minute))
+	c.Set(1, 1)
+	if _, ok := c.Get(1); !ok {
+		t.Fatal("expected hit before TTL elapsed")
+	}
+	advance(time.Minute)
+	if _, ok := c.Peek(1); ok {
+		t.Fatal("expected Peek miss after TTL elapsed")
+	}
+	if _, ok := c.Get(1); ok {
+		t.Fatal("expected Get miss after TTL elapsed")
+	}
+	if c.Len() != 0 {
+		t.Fatalf("expected expired entry to be reclaimed on access, Len %d", c.Len())
+	}
+}
+
+func TestSetWithTTLOverridesDefault(t *testing.T) {
+	advance := fakeClock(t)
+	c := NewWithOptions[int, int](10, WithTTL(time.Minute))
+	c.SetWithTTL(1, 1, 0)
+	c.SetWithTTL(2, 2, time.Second)
+	c.Set(3, 3)
+	advance(2 * time.Second)
+	if _, ok := c.Get(1); !ok {
+		t.Fatal("expected entry set with zero TTL not to expire")
+	}
+	if _, ok := c.Get(2); ok {
+		t.Fatal("expected entry with short TTL to expire")
+	}
+	if _, ok := c.Get(3); !ok {
+		t.Fatal("expected entry with default TTL to remain")
+	}
+
+	// Overwriting with Set applies the default TTL again.
+	c.Set(1, 10)
+	advance(time.Minute)
+	if _, ok := c.Get(1); ok {
+		t.Fatal("expected overwritten entry to take the default TTL")
+	}
+}
+
+func TestExpiredEvictedBeforeLive(t *testing.T) {
+	advance := fakeClock(t)
+	c := New[int, int](4)
+	c.Set(1, 1)
+	c.SetWithTTL(2, 2, time.Second)
+	c.SetWithTTL(3, 3, time.Second)
+	c.Set(4, 4)
+	advance(time.Second)
+	c.Set(5, 5)
+	c.Set(6, 6)
+	for _, k := range []int{1, 4, 5, 6} {
+		if _, ok := c.Peek(k); !ok {
+			t.Fatalf("expected live key %d to remain while expired keys are reclaimed", k)
+		}
+	}
+}
+
+func TestOnlyExpiredEvictedBeforeLive(t *testing.T) {
+	advance := fakeClock(t)
+	c := NewWithOptions[int, int](1000, WithTTL(time.Hour))
+	for k := 0; k < 999; k++ {
+		c.Set(k, k)
+	}
+	c.SetWithTTL(999, 999, time.Second)
+	advance(time.Second)
+	// The single expired entry is found among 1000 deadlines and evicted
+	// instead of a live entry, whether the cache shrinks or a key is added.
+	c.Resize(999)
+	c.Resize(1000)
+	c.SetWithTTL(1001, 1001, time.Second)
+	advance(time.Second)
+	c.Set(1002, 1002)
+	for k := 0; k < 999; k++ {
+		if _, ok := c.Peek(k); !ok {
+			t.Fatalf("expected live key %d to remain", k)
+		}
+	}
+	if _, ok := c.Peek(1002); !ok || c.Len() != 1000 {
+		t.Fatalf("expected key 1002 to replace the expired key 1001, Len %d", c.Len())
+	}
+}
+
+func TestDeadlineHeapStaysCompact(t *testing.T) {
+	advance := fakeClock(t)
+	c := NewWithOptions[int, int](10, WithTTL(time.Minute))
+	for i := 0; i < 10_000; i++ {
+		c.Set(i%10, i)
+		advance(time.Millisecond)
+	}
+	s := c.shards[0]
+	if n := len(s.deadlines); n > 2*len(s.expiry)+16 {
+		t.Fatalf("expected overwritten deadlines to be compacted, heap holds %d for %d keys", n, len(s.expiry))
+	}
+	advance(time.Minute)
+	if n := c.DeleteExpired(); n != 10 {
+		t.Fatalf("expected 10 expired entries, got %d", n)
+	}
+}
+
+func TestDeleteExpired(t *testing.T) {
+	advance := fakeClock(t)
+	c := NewWithOptions[int, int](1024, WithShards(4), WithTTL(time.Second))
+	for i := 0; i < 100; i++ {
+		c.Set(i, i)
+	}
+	c.SetWithTTL(100, 100, time.Hour)
+	advance(time.Second)
+	if c.Remove(0) {
+		t.Fatal("expected Remove to report an expired key as absent")
+	}
+	if n := c.DeleteExpired(); n != 99 {
+		t.Fatalf("expected 99 expired entries, got %d", n)
+	}
+	if c.Len() != 1 {
+		t.Fatalf("expected Len 1, got %d", c.Len())
+	}
+}
@@ -0,0 +1,10 @@
+package lfu
+
+// Hasher hashes a key for shard routing and, with WithAdmission, for the
+// admission filter's frequency sketch. It must return the same value for
+// equal keys, should spread distinct keys evenly over all 64 bits, and must be
+// safe for concurrent use. seed is chosen at random for each cache, or derived
+// from the seed given to WithSeed, and should be mixed into the result (for
+// example with maphash.Comparable or by starting an FNV hash from it) so hash
+// values are not predictable across caches.
+type Hasher[K comparable] func(seed uint64, key K) uint64
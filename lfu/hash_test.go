@@ -0,0 +1,97 @@
+package lfu
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+)
+
+type compositeKey struct {
+	Tenant int32
+	// Padding after Tenant must not affect the hash.
+	ID    int64
+	Name  string
+	Score float64
+	_     int64
+	Flags [3]bool
+}
+
+func TestHashEqualKeys(t *testing.T) {
+	c := NewSharded[compositeKey, int](4096, 16)
+	a := compositeKey{Tenant: 1, ID: 2, Name: "x", Score: 0}
+	b := compositeKey{Tenant: 1, ID: 2, Name: string([]byte{'x'}), Score: math.Copysign(0, -1)}
+	if a != b {
+		t.Fatal("test keys should be equal")
+	}
+	if c.hash(a) != c.hash(b) {
+		t.Fatal("expected equal keys to hash equally")
+	}
+	if c.hash(a) == c.hash(compositeKey{Tenant: 1, ID: 2, Name: "y"}) {
+		t.Fatal("expected keys with different strings to hash differently")
+	}
+
+	f := NewSharded[float64, int](4096, 16)
+	if f.hash(0) != f.hash(math.Copysign(0, -1)) {
+		t.Fatal("expected -0 and +0 to hash equally")
+	}
+	z := NewSharded[complex128, int](4096, 16)
+	if z.hash(complex(0, 1)) != z.hash(complex(math.Copysign(0, -1), 1)) {
+		t.Fatal("expected complex -0 and +0 to hash equally")
+	}
+}
+
+func TestHashDoesNotAllocate(t *testing.T) {
+	test := func(name string, get func()) {
+		if n := testing.AllocsPerRun(100, get); n != 0 {
+			t.Errorf("%s: expected Get not to allocate, got %v allocs", name, n)
+		}
+	}
+	ck := NewSharded[compositeKey, int](4096, 16)
+	test("struct", func() { ck.Get(compositeKey{ID: 1, Name: "x"}) })
+	bk := NewSharded[[16]byte, int](4096, 16)
+	test("[16]byte", func() { bk.Get([16]byte{1}) })
+	type id uint32
+	ik := NewSharded[id, int](4096, 16)
+	test("named", func() { ik.Get(id(1)) })
+	fk := NewSharded[float64, int](4096, 16)
+	test("float64", func() { fk.Get(1.5) })
+	zk := NewSharded[complex64, int](4096, 16)
+	test("complex64", func() { zk.Get(1i) })
+}
+
+func TestHashSpreadsArrayKeys(t *testing.T) {
+	c := NewSharded[[16]byte, int](4096, 16)
+	used := map[int]bool{}
+	for i := 0; i < 1000; i++ {
+		used[c.shardIndex([16]byte{15: byte(i), 14: byte(i >> 8)})] = true
+	}
+	if len(used) != 16 {
+		t.Fatalf("expected keys to reach all 16 shards, reached %d", len(used))
+	}
+}
+
+func TestWithHasher(t *testing.T) {
+	type key struct {
+		id   int
+		meta any
+	}
+	var calls atomic.Int32
+	c := NewWithOptions[key, int](4096, WithShards(16), WithHasher(func(seed uint64, k key) uint64 {
+		calls.Add(1)
+		return seed ^ uint64(k.id)*0x9e3779b97f4a7c15
+	}))
+	c.Set(key{id: 1}, 1)
+	if v, ok := c.Get(key{id: 1}); !ok || v != 1 {
+		t.Fatalf("expected hit, got %d, %v", v, ok)
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected the hasher to be called twice, got %d", calls.Load())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a hasher for the wrong key type to panic")
+		}
+	}()
+	NewWithOptions[string, int](4096, WithShards(16), WithHasher(func(seed uint64, k int) uint64 { return 0 }))
+}
@@ -0,0 +1,102 @@
+package lfu
+
+import (
+	"sort"
+
+	"github.com/tysonmote/cache/internal/sketch"
+)
+
+// HotKey is a cached key reported by HotKeys.
+type HotKey[K comparable] struct {
+	Key K
+	// Bucket is the key's frequency bucket; see KeyInfo.
+	Bucket int
+	// Count is the number of Get hits on the key since it was added, if the
+	// cache was created with WithAccessCounts, and 0 otherwise.
+	Count uint64
+}
+
+// HeavyHitter is a frequently looked-up key reported by HeavyHitters.
+type HeavyHitter[K comparable] struct {
+	Key K
+	// Count estimates the key's Get lookups, hits and misses alike. It may
+	// overcount, by at most Err.
+	Count uint64
+	Err   uint64
+}
+
+// HotKeys returns up to n cached keys with the highest estimated frequency,
+// hottest first: keys in higher frequency buckets come first and, with
+// WithAccessCounts, keys in the same bucket are ordered by Count. Without
+// access counts, the order of keys within a bucket is unspecified.
+//
+// Each shard's mutex is held only while the shard's candidates are copied: up
+// to n keys from its highest buckets or, with access counts, every key of the
+// highest buckets holding its first n keys.
+func (c *Cache[K, V]) HotKeys(n int) []HotKey[K] {
+	if n <= 0 {
+		return nil
+	}
+	var hot []HotKey[K]
+	for _, s := range c.shards {
+		hot = s.appendHot(hot, n)
+	}
+	sort.Slice(hot, func(i, j int) bool {
+		if hot[i].Bucket != hot[j].Bucket {
+			return hot[i].Bucket > hot[j].Bucket
+		}
+		return hot[i].Count > hot[j].Count
+	})
+	if len(hot) > n {
+		hot = hot[:n]
+	}
+	return hot
+}
+
+// HeavyHitters returns up to n of the keys most often looked up with Get, most
+// frequent first, as estimated by the sketch enabled by WithHeavyHitters.
+// Unlike HotKeys, it includes keys that are no longer cached, so it shows load
+// skew even when hot keys churn. It returns nil if the sketch is not enabled.
+func (c *Cache[K, V]) HeavyHitters(n int) []HeavyHitter[K] {
+	if n <= 0 || c.shards[0].heavy == nil {
+		return nil
+	}
+	// A key is counted by one shard only, so the shards' summaries merge by
+	// concatenation.
+	var all []sketch.Counter[K]
+	for _, s := range c.shards {
+		s.mu.Lock()
+		all = append(all, s.heavy.Top(n)...)
+		s.mu.Unlock()
+	}
+	sketch.SortCounters(all)
+	if len(all) > n {
+		all = all[:n]
+	}
+	hh := make([]HeavyHitter[K], len(all))
+	for i, c := range all {
+		hh[i] = HeavyHitter[K]{Key: c.Key, Count: c.Count, Err: c.Err}
+	}
+	return hh
+}
+
+func (s *lfuShard[K, V]) appendHot(dst []HotKey[K], n int) []HotKey[K] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := nowNano()
+	got := 0
+	for b := len(s.buckets) - 1; b >= 0 && got < n; b-- {
+		for k := range s.buckets[b] {
+			// With counts, a bucket is taken whole so its keys can be ranked.
+			if s.counts == nil && got == n {
+				break
+			}
+			if s.expiredAt(k, now) {
+				continue
+			}
+			dst = append(dst, HotKey[K]{Key: k, Bucket: b, Count: s.counts[k]})
+			got++
+		}
+	}
+	return dst
+}
@@ -0,0 +1,76 @@
+package lfu
+
+import "testing"
+
+func TestHotKeys(t *testing.T) {
+	c := NewWithOptions[int, int](4096, WithShards(4), WithAccessCounts(), WithPromotionCurve([]float64{1, 0}))
+	for k := 0; k < 100; k++ {
+		c.Set(k, k)
+	}
+	// Keys 0-9 reach bucket 1 with k+1 hits each; key 10 stays in bucket 0.
+	for k := 0; k < 10; k++ {
+		for i := 0; i <= k; i++ {
+			c.Get(k)
+		}
+	}
+	hot := c.HotKeys(5)
+	if len(hot) != 5 {
+		t.Fatalf("expected 5 hot keys, got %d", len(hot))
+	}
+	for i, h := range hot {
+		if want := 9 - i; h.Key != want || h.Bucket != 1 || h.Count != uint64(want+1) {
+			t.Fatalf("hot key %d: expected key %d with %d hits in bucket 1, got %+v", i, want, want+1, h)
+		}
+	}
+	if info, _ := c.Inspect(9); info.Count != 10 {
+		t.Fatalf("expected Inspect to report 10 hits, got %d", info.Count)
+	}
+	c.Set(9, 9)
+	if info, _ := c.Inspect(9); info.Count != 0 {
+		t.Fatalf("expected Set to reset the access count, got %d", info.Count)
+	}
+
+	if n := len(c.HotKeys(1000)); n != 100 {
+		t.Fatalf("expected every key when n exceeds Len, got %d", n)
+	}
+	if c.HotKeys(0) != nil {
+		t.Fatal("expected no hot keys for n = 0")
+	}
+}
+
+func TestHeavyHitters(t *testing.T) {
+	if New[int, int](10).HeavyHitters(10) != nil {
+		t.Fatal("expected nil without WithHeavyHitters")
+	}
+
+	c := NewWithOptions[int, int](256, WithShards(4), WithHeavyHitters(16))
+	// Keys 0-2 are looked up far more often than a stream of one-off keys,
+	// and key 0 is never cached.
+	cold := 1000
+	for i := 0; i < 30_000; i++ {
+		switch i % 10 {
+		case 0, 1, 2:
+			c.Get(0)
+		case 3, 4:
+			c.Get(1)
+		case 5:
+			c.Get(2)
+		default:
+			c.Get(cold)
+			c.Set(cold, cold)
+			cold++
+		}
+	}
+	hh := c.HeavyHitters(3)
+	if len(hh) != 3 {
+		t.Fatalf("expected 3 heavy hitters, got %d", len(hh))
+	}
+	for i, h := range hh {
+		if h.Key != i {
+			t.Fatalf("expected key %d at %d, got %+v", i, i, hh)
+		}
+	}
+	if hh[0].Count < 9000 || hh[0].Count-hh[0].Err > 9000 {
+		t.Fatalf("expected key 0's count bounds to include 9000, got %+v", hh[0])
+	}
+}
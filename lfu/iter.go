@@ -0,0 +1,74 @@
+package lfu
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// Range calls fn for each live entry in the cache until fn returns false. It
+// does not update access frequencies. Entries are visited in unspecified order.
+//
+// Range copies one shard's entries at a time while holding that shard's mutex,
+// then calls fn without any lock held, so fn may call back into the cache and a
+// long scan does not block other operations. Each shard is a consistent
+// snapshot as of the moment it was copied, but the cache as a whole is not:
+// changes to a shard after it is copied are not seen, and changes to shards not
+// yet copied are.
+func (c *Cache[K, V]) Range(fn func(key K, value V) bool) {
+	var buf []entry[K, V]
+	for _, s := range c.shards {
+		buf = s.appendEntries(buf[:0])
+		for _, e := range buf {
+			if !fn(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns the keys of all live entries in the cache, in unspecified
+// order, without updating access frequencies. It has the same per-shard
+// consistency as Range.
+func (c *Cache[K, V]) Keys() []K {
+	var keys []K
+	for _, s := range c.shards {
+		s.mu.Lock()
+		if keys == nil {
+			keys = make([]K, 0, len(s.index)*len(c.shards))
+		}
+		now := nowNano()
+		for k := range s.index {
+			if !s.expiredAt(k, now) {
+				keys = append(keys, k)
+			}
+		}
+		s.mu.Unlock()
+	}
+	return keys
+}
+
+// appendEntries appends the shard's live entries to dst, most frequently used
+// buckets first.
+func (s *lfuShard[K, V]) appendEntries(dst []entry[K, V]) []entry[K, V] {
+	s.mu.Lock()
+	now := nowNano()
+	for i := len(s.buckets) - 1; i >= 0; i-- {
+		for k, v := range s.buckets[i] {
+			if !s.expiredAt(k, now) {
+				dst = append(dst, entry[K, V]{k, v})
+			}
+		}
+	}
+	s.mu.Unlock()
+	return dst
+}
+
+// expiredAt reports whether key has a deadline at or before now, without
+// reclaiming it.
+func (s *lfuShard[K, V]) expiredAt(key K, now int64) bool {
+	if len(s.expiry) == 0 {
+		return false
+	}
+	deadline, ok := s.expiry[key]
+	return ok && now >= deadline
+}
@@ -0,0 +1,14 @@
+//go:build go1.23
+
+package lfu
+
+import "iter"
+
+// All returns an iterator over the cache's live entries. It has the same
+// semantics as Range: access frequencies are not updated, and each shard is
+// copied under its mutex before its entries are yielded.
+func (c *Cache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		c.Range(yield)
+	}
+}
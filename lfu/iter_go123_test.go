@@ -0,0 +1,22 @@
+//go:build go1.23
+
+package lfu
+
+import "testing"
+
+func TestAll(t *testing.T) {
+	c := New[int, int](10)
+	for i := 0; i < 5; i++ {
+		c.Set(i, i)
+	}
+	sum := 0
+	for k, v := range c.All() {
+		if k != v {
+			t.Fatalf("expected value %d for key %d, got %d", k, k, v)
+		}
+		sum += k
+	}
+	if sum != 10 {
+		t.Fatalf("expected to visit keys 0-4, got sum %d", sum)
+	}
+}
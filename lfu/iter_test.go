@@ -0,0 +1,58 @@
+package lfu
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestRangeAndKeys(t *testing.T) {
+	advance := fakeClock(t)
+	c := NewSharded[int, int](1024, 4)
+	for i := 0; i < 100; i++ {
+		c.Set(i, i*10)
+	}
+	c.SetWithTTL(100, 1000, time.Second)
+	advance(time.Second)
+
+	seen := map[int]int{}
+	c.Range(func(k, v int) bool {
+		seen[k] = v
+		return true
+	})
+	if len(seen) != 100 {
+		t.Fatalf("expected 100 live entries, got %d", len(seen))
+	}
+	for k, v := range seen {
+		if v != k*10 {
+			t.Fatalf("expected value %d for key %d, got %d", k*10, k, v)
+		}
+	}
+
+	keys := c.Keys()
+	sort.Ints(keys)
+	if len(keys) != 100 || keys[0] != 0 || keys[99] != 99 {
+		t.Fatalf("expected keys 0-99, got %d keys", len(keys))
+	}
+
+	n := 0
+	c.Range(func(k, v int) bool {
+		n++
+		return n < 10
+	})
+	if n != 10 {
+		t.Fatalf("expected Range to stop after 10 entries, got %d", n)
+	}
+}
+
+func TestRangeDoesNotPromote(t *testing.T) {
+	c := New[int, int](10)
+	c.Set(1, 1)
+	c.Range(func(k, v int) bool {
+		c.Set(k+1, v) // callbacks may use the cache
+		return true
+	})
+	if st := c.Stats(); st.Buckets[0] != 2 {
+		t.Fatalf("expected entries to stay in bucket 0, got %v", st.Buckets)
+	}
+}
@@ -1,19 +1,28 @@
 package lfu
 
 import (
-	"hash/maphash"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/tysonmote/cache/internal/keyhash"
+	"github.com/tysonmote/cache/internal/sketch"
+	"github.com/tysonmote/cache/internal/split"
 )
 
 const (
-	numBuckets     int8    = 4
-	maxBucketIndex int8    = numBuckets - 1
-	promoteBase    float64 = 0.01
+	defaultNumBuckets  = 4
+	defaultPromoteBase = 0.01
+	// maxNumBuckets is the most buckets a shard may have; bucket indexes are
+	// stored as int8.
+	maxNumBuckets = 127
 )
 
-// minKeysPerShard avoids splitting a tiny total capacity across many stripes:
-// with one key per shard, distinct keys can collide on the same shard and
-// evict each other immediately. Larger caches still use up to numShards stripes.
-const minKeysPerShard = 64
+// maxSketchWidth bounds the per-shard admission sketch (see WithAdmission) for
+// very large or cost-aware shards, whose capacity may far exceed their entry
+// count.
+const maxSketchWidth = 1 << 22
 
 // Cache is a thread-safe, fixed-size, in-memory cache with a probabilistic
 // least-frequently-used eviction policy. If the cache is full and a new item is
@@ -23,84 +32,169 @@ const minKeysPerShard = 64
 //
 // Overwriting an existing key with Set resets that key's frequency to one
 // access; the key is moved to the lowest-frequency bucket as if it were newly
-// added.
+// added. Update, or Set with WithPreserveFrequency, keeps the key's frequency
+// instead.
 //
 // The probabilistic eviction policy is faster and more memory efficient than
 // the approach described in the "An O(1) algorithm for implementing the Cache
-// cache eviction scheme" paper: https://arxiv.org/pdf/2110.11602.pdf
+// cache eviction scheme" paper: https://arxiv.org/pdf/2110.11602.pdf, which
+// the exactlfu package implements for comparison.
 //
 // A Cache is implemented as one or more internal shards (stripes), each with
-// its own mutex. New uses a single shard. NewSharded uses multiple shards so
-// concurrent operations on different keys can proceed in parallel; eviction is
-// local to each shard. The effective stripe count is at most numShards and is
-// reduced when size is small so each shard holds at least minKeysPerShard keys
-// on average (except a single zero-capacity shard when size is 0).
+// its own mutex. New uses a single shard. NewSharded (or WithShards) uses
+// multiple shards so concurrent operations on different keys can proceed in
+// parallel; eviction is local to each shard. The effective stripe count is at
+// most the requested number and is reduced when size is small so each shard
+// holds at least 64 keys on average (except a single zero-capacity shard when
+// size is 0).
+//
+// Each shard keeps its entries in a small number of frequency buckets (4 by
+// default). A new key enters bucket 0, and each Get promotes a key from bucket
+// i to bucket i+1 with a probability given by the promotion curve; eviction
+// takes a key from the lowest non-empty bucket. WithBuckets,
+// WithPromotionBase, and WithPromotionCurve tune this structure.
+//
+// Entries may carry a deadline, set by the WithTTL option or SetWithTTL. An
+// expired entry misses on Get and Peek. Each shard keeps its deadlines in a
+// min-heap and reclaims expired entries lazily on access, before adding a key,
+// and before evicting, so expired entries are always evicted before live ones;
+// DeleteExpired reclaims all of them. Until reclaimed, expired entries are
+// included in Len.
+//
+// By default capacity counts entries. WithCostCapacity and WithWeigher make it
+// a budget of per-entry costs instead, such as bytes.
 type Cache[K comparable, V any] struct {
 	shards []*lfuShard[K, V]
-	seed   maphash.Seed
+
+	// keys hashes keys for routing and admission. hasher, if set by
+	// WithHasher, replaces it and is called with hasherSeed.
+	keys       *keyhash.Func[K]
+	hasher     Hasher[K]
+	hasherSeed uint64
+
+	// weighted records whether capacity is a cost budget, for Resize.
+	weighted bool
+	// budget is the capacity shared by all shards with WithStrictCapacity.
+	budget   *budget
+	resizeMu sync.Mutex
+
+	codec Codec
+}
+
+// EvictReason describes why an entry left the cache. See WithOnEvict.
+type EvictReason uint8
+
+const (
+	// EvictCapacity means the entry was evicted to make room for another, or
+	// was not retained because the cache has no capacity.
+	EvictCapacity EvictReason = iota
+	// EvictRemoved means the entry was deleted by Remove.
+	EvictRemoved
+	// EvictReplaced means the entry's value was overwritten by Set. The
+	// callback receives the old value.
+	EvictReplaced
+	// EvictCleared means the entry was deleted by Clear.
+	EvictCleared
+	// EvictExpired means the entry's TTL elapsed.
+	EvictExpired
+	// EvictRejected means a new entry was not admitted because the admission
+	// policy (see WithAdmission) judged it less valuable than the entry it
+	// would have displaced.
+	EvictRejected
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictRemoved:
+		return "removed"
+	case EvictReplaced:
+		return "replaced"
+	case EvictCleared:
+		return "cleared"
+	case EvictExpired:
+		return "expired"
+	case EvictRejected:
+		return "rejected"
+	default:
+		return "EvictReason(" + strconv.Itoa(int(r)) + ")"
+	}
 }
 
 // New returns a new Cache ready for use with a maximum capacity of size
 // items. A size of 0 disables caching: Set does not retain entries and Get
-// always misses.
+// always misses. It is shorthand for NewWithOptions with no options, so the
+// cache has a single shard; use NewWithOptions to configure it.
 func New[K comparable, V any](size int) *Cache[K, V] {
-	return NewSharded[K, V](size, 1)
+	return NewWithOptions[K, V](size)
 }
 
-// NewSharded returns a cache with up to numShards stripes. The sum of per-shard
-// limits is at least size (often slightly more per shard to absorb hash skew),
-// so the cache may hold more than size entries under a striped layout. When
-// size is small relative to numShards, fewer stripes are used so each holds at
-// least about minKeysPerShard items on average.
-//
-// Key-to-shard routing uses hash/maphash with a per-cache random seed. int and
-// other common primitive keys use fast encodings; other comparable types fall
-// back to a string representation for hashing.
+// NewSharded returns a cache with up to numShards stripes. It is shorthand for
+// NewWithOptions with WithShards(numShards); see WithShards for how capacity
+// is split across shards.
 func NewSharded[K comparable, V any](size, numShards int) *Cache[K, V] {
-	if numShards < 1 {
-		panic("lfu: numShards must be at least 1")
-	}
-	effective := numShards
-	if size == 0 {
-		effective = 1
-	} else {
-		maxStripes := size / minKeysPerShard
-		if maxStripes < 1 {
-			maxStripes = 1
-		}
-		if effective > maxStripes {
-			effective = maxStripes
-		}
+	return NewWithOptions[K, V](size, WithShards(numShards))
+}
+
+// NewWithOptions returns a new Cache with a maximum capacity of size items (or
+// cost, see WithCostCapacity), configured by opts. A size of 0 disables
+// caching: Set does not retain entries and Get always misses.
+//
+// Key-to-shard routing uses hash/maphash with a per-cache random seed, or a
+// fixed seed given by WithSeed. Keys of predeclared types, and arrays, structs,
+// and named types built from them, are hashed without allocating; keys that
+// contain interfaces fall back to a string representation. WithHasher replaces
+// the built-in hashing.
+func NewWithOptions[K comparable, V any](size int, opts ...Option) *Cache[K, V] {
+	if size < 0 {
+		panic("lfu: size must not be negative")
 	}
-	caps := distributeCapacity(size, effective)
-	shards := make([]*lfuShard[K, V], effective)
-	for i := range shards {
-		shards[i] = newLFUShard[K, V](caps[i])
+	o := newOptions(opts)
+	effective := split.Shards(size, o.shards)
+	c := &Cache[K, V]{
+		shards:   make([]*lfuShard[K, V], effective),
+		weighted: o.costCapacity,
+		codec:    o.codec,
 	}
-	return &Cache[K, V]{
-		shards: shards,
-		seed:   maphash.MakeSeed(),
+	if o.seeded {
+		c.keys = keyhash.NewSeeded[K](o.seed)
+	} else {
+		c.keys = keyhash.New[K]()
 	}
-}
-
-func distributeCapacity(size, n int) []int {
-	caps := make([]int, n)
-	if n == 0 || size == 0 {
-		return caps
+	if o.hasher != nil {
+		fn, ok := o.hasher.(Hasher[K])
+		if !ok {
+			panic(fmt.Sprintf("lfu: WithHasher function is %T, want %T", o.hasher, fn))
+		}
+		c.hasher = fn
+		c.hasherSeed = c.keys.Seed()
 	}
-	if n == 1 {
-		caps[0] = size
-		return caps
+	shardCap := split.Capacity(size, effective, o.costCapacity)
+	if o.strict {
+		c.budget = &budget{}
+		c.budget.limit.Store(int64(size))
 	}
-	// Ceil(size/n) per shard plus slack: hashing is not perfectly uniform, so a
-	// tight sum==size split can overflow a shard during load and evict keys that
-	// other stripes still have room for. Total max entries can exceed size.
-	base := (size + n - 1) / n
-	slack := max(512, min(2048, max(1, base/4)))
-	for i := range caps {
-		caps[i] = base + slack
+	for i := range c.shards {
+		cap := shardCap
+		if c.budget != nil {
+			// Each shard may take the whole budget; the budget, not the
+			// shard, bounds its size.
+			cap = size
+		}
+		s := newLFUShard[K, V](i, cap, o)
+		if o.admission {
+			s.hash = c.hash
+			s.admission = sketch.NewTinyLFU(min(max(shardCap, 1), maxSketchWidth))
+		}
+		if c.budget != nil {
+			s.budget = c.budget
+			s.peers = c.shards
+			s.next = i
+		}
+		c.shards[i] = s
 	}
-	return caps
+	return c
 }
 
 func max(a, b int) int {
@@ -121,10 +215,14 @@ func (c *Cache[K, V]) shardIndex(key K) int {
 	if len(c.shards) == 1 {
 		return 0
 	}
-	var h maphash.Hash
-	h.SetSeed(c.seed)
-	writeKey(&h, key)
-	return int(h.Sum64() % uint64(len(c.shards)))
+	return int(c.hash(key) % uint64(len(c.shards)))
+}
+
+func (c *Cache[K, V]) hash(key K) uint64 {
+	if c.hasher != nil {
+		return c.hasher(c.hasherSeed, key)
+	}
+	return c.keys.Sum64(key)
 }
 
 // Get returns a value from the cache if it exists. If the value does not
@@ -146,7 +244,24 @@ func (c *Cache[K, V]) Peek(key K) (v V, ok bool) {
 // to be the least frequently used item because LFU uses a probabilistic
 // approach to tracking item access frequency.
 func (c *Cache[K, V]) Set(key K, value V) {
-	c.shards[c.shardIndex(key)].set(key, value)
+	s := c.shards[c.shardIndex(key)]
+	s.set(key, value, s.ttl, s.weigh(key, value))
+}
+
+// SetWithTTL is like Set but the entry expires after ttl instead of the
+// cache's default TTL. A ttl of zero or less means the entry does not expire.
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	s := c.shards[c.shardIndex(key)]
+	s.set(key, value, ttl, s.weigh(key, value))
+}
+
+// SetWithCost is like Set but charges the entry cost against a cost-aware
+// cache's budget instead of the weigher's result (see WithCostCapacity). A
+// negative cost is treated as zero. For a cache that counts entries, cost is
+// ignored and SetWithCost behaves like Set.
+func (c *Cache[K, V]) SetWithCost(key K, value V, cost int64) {
+	s := c.shards[c.shardIndex(key)]
+	s.set(key, value, s.ttl, cost)
 }
 
 // Remove deletes a key from the cache. It returns true if the key was present.
@@ -154,6 +269,17 @@ func (c *Cache[K, V]) Remove(key K) bool {
 	return c.shards[c.shardIndex(key)].remove(key)
 }
 
+// DeleteExpired removes all expired entries and returns how many were removed.
+// Shards are swept one at a time, so concurrent operations on other shards are
+// not blocked.
+func (c *Cache[K, V]) DeleteExpired() int {
+	n := 0
+	for _, s := range c.shards {
+		n += s.deleteExpired()
+	}
+	return n
+}
+
 // Clear removes all entries from the cache.
 func (c *Cache[K, V]) Clear() {
 	for _, s := range c.shards {
@@ -161,6 +287,64 @@ func (c *Cache[K, V]) Clear() {
 	}
 }
 
+// Cost returns the total cost of the entries in a cost-aware cache (see
+// WithCostCapacity). For a cache that counts entries it is the same as Len.
+func (c *Cache[K, V]) Cost() int64 {
+	var n int64
+	for _, s := range c.shards {
+		s.mu.Lock()
+		n += s.costLocked()
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Resize changes the cache's capacity to size, splitting it across the
+// existing shards as NewSharded does; the number of shards does not change.
+// When shrinking, each shard evicts low-frequency entries until it fits its new
+// limit; with WithStrictCapacity, entries are evicted only if the cache holds
+// more than the new size, and only until the whole cache fits it. A size of 0
+// empties the cache and disables caching. Resize may be called concurrently
+// with other operations; shards are resized one at a time, so operations on
+// other shards are not blocked.
+func (c *Cache[K, V]) Resize(size int) {
+	if size < 0 {
+		panic("lfu: size must not be negative")
+	}
+	c.resizeMu.Lock()
+	defer c.resizeMu.Unlock()
+	if c.budget != nil {
+		// Lower the limit first so new entries cannot take the room being
+		// freed, then evict from each shard in proportion to what it holds
+		// until the whole cache fits. Growing never evicts.
+		limit := int64(size)
+		c.budget.limit.Store(limit)
+		used := c.budget.used.Load()
+		for _, s := range c.shards {
+			s.shrink(limit, used)
+		}
+		// Rounding can leave the cache just over the limit.
+		for evicted := true; evicted && c.budget.used.Load() > limit; {
+			evicted = false
+			for _, s := range c.shards {
+				if c.budget.used.Load() <= limit {
+					break
+				}
+				s.mu.Lock()
+				if s.evict() {
+					evicted = true
+				}
+				s.unlock()
+			}
+		}
+		return
+	}
+	cap := split.Capacity(size, len(c.shards), c.weighted)
+	for _, s := range c.shards {
+		s.resize(cap)
+	}
+}
+
 // Len returns the number of entries in the cache.
 func (c *Cache[K, V]) Len() int {
 	n := 0
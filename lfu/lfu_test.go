@@ -24,6 +24,22 @@ func TestShardedCache(t *testing.T) {
 	}
 }
 
+func TestRandomOps(t *testing.T) {
+	err := cachetest.TestRandomOps(func(size int) cachetest.Cache[int, int] {
+		return New[int, int](size)
+	}, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A strict capacity bounds Len across shards too.
+	err = cachetest.TestRandomOps(func(size int) cachetest.Cache[int, int] {
+		return NewWithOptions[int, int](size, WithShards(4), WithStrictCapacity())
+	}, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestZeroCapacityDoesNotRetain(t *testing.T) {
 	c := New[int, int](0)
 	for i := 0; i < 1000; i++ {
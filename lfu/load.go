@@ -0,0 +1,120 @@
+package lfu
+
+import (
+	"context"
+	"time"
+)
+
+// loadCall is an in-flight GetOrLoad load shared by every caller waiting on the
+// same key.
+type loadCall[V any] struct {
+	done     chan struct{}
+	value    V
+	err      error
+	panicked any
+
+	// waiters is the number of callers still waiting, guarded by the shard's
+	// mutex. When the last one gives up, cancel stops the load and the call
+	// is forgotten, so a later GetOrLoad starts a new one.
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// GetOrLoad returns the value for key, calling loader to produce it on a miss.
+// Concurrent GetOrLoad calls that miss on the same key share a single call to
+// loader, and a successful result is added to the cache as if by Set. A loader
+// error is returned to every waiting caller and is not cached, so the next
+// call tries again.
+//
+// Each caller waits until the load finishes or its own ctx is done, in which
+// case it returns ctx.Err() while the load continues for the other callers.
+// loader runs on its own goroutine with a context that carries the values of
+// the first caller's ctx; that context is canceled once every caller has given
+// up. If loader panics, the panic is propagated to every waiting caller.
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, loader func(ctx context.Context, key K) (V, error)) (V, error) {
+	return c.shards[c.shardIndex(key)].getOrLoad(ctx, key, loader)
+}
+
+func (s *lfuShard[K, V]) getOrLoad(ctx context.Context, key K, loader func(context.Context, K) (V, error)) (V, error) {
+	s.mu.Lock()
+	if v, ok := s.getLocked(key); ok {
+		s.unlock()
+		return v, nil
+	}
+	call, ok := s.calls[key]
+	if !ok {
+		lctx, cancel := context.WithCancel(detachedContext{ctx})
+		call = &loadCall[V]{done: make(chan struct{}), cancel: cancel}
+		if s.calls == nil {
+			s.calls = map[K]*loadCall[V]{}
+		}
+		s.calls[key] = call
+		go s.load(lctx, key, call, loader)
+	}
+	call.waiters++
+	s.unlock()
+
+	select {
+	case <-call.done:
+		if call.panicked != nil {
+			panic(call.panicked)
+		}
+		return call.value, call.err
+	case <-ctx.Done():
+		s.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			call.cancel()
+			s.forget(key, call)
+		}
+		s.mu.Unlock()
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+func (s *lfuShard[K, V]) load(ctx context.Context, key K, call *loadCall[V], loader func(context.Context, K) (V, error)) {
+	defer close(call.done)
+	defer call.cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			call.panicked = r
+			s.mu.Lock()
+			s.forget(key, call)
+			s.mu.Unlock()
+		}
+	}()
+
+	v, err := loader(ctx, key)
+	call.value, call.err = v, err
+	var cost int64
+	if err == nil {
+		cost = s.weigh(key, v)
+	}
+
+	s.mu.Lock()
+	s.forget(key, call)
+	if err == nil {
+		s.setLocked(key, v, s.ttl, cost)
+	}
+	s.unlock()
+}
+
+// forget removes call from the in-flight loads unless it has already been
+// replaced by a newer call for the same key.
+func (s *lfuShard[K, V]) forget(key K, call *loadCall[V]) {
+	if s.calls[key] == call {
+		delete(s.calls, key)
+	}
+}
+
+// detachedContext carries the values of its parent but not its deadline or
+// cancellation, so one caller giving up does not cancel a load that other
+// callers are still waiting on.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
@@ -0,0 +1,166 @@
+package lfu
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoadCoalesces(t *testing.T) {
+	c := NewSharded[int, int](1024, 4)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context, k int) (int, error) {
+		calls.Add(1)
+		<-release
+		return k * 2, nil
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.GetOrLoad(context.Background(), 21, loader)
+			if err == nil && v != 42 {
+				err = errors.New("unexpected value")
+			}
+			errs <- err
+		}()
+	}
+	for calls.Load() == 0 {
+		runtime.Gosched()
+	}
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected 1 loader call, got %d", n)
+	}
+	if v, ok := c.Peek(21); !ok || v != 42 {
+		t.Fatalf("expected loaded value to be cached, got %d, %v", v, ok)
+	}
+}
+
+func TestGetOrLoadErrorNotCached(t *testing.T) {
+	c := New[int, int](10)
+	errLoad := errors.New("backend down")
+	_, err := c.GetOrLoad(context.Background(), 1, func(ctx context.Context, k int) (int, error) {
+		return 0, errLoad
+	})
+	if !errors.Is(err, errLoad) {
+		t.Fatalf("expected loader error, got %v", err)
+	}
+	if _, ok := c.Peek(1); ok {
+		t.Fatal("expected failed load not to be cached")
+	}
+	v, err := c.GetOrLoad(context.Background(), 1, func(ctx context.Context, k int) (int, error) {
+		return 7, nil
+	})
+	if err != nil || v != 7 {
+		t.Fatalf("expected retry to load 7, got %d, %v", v, err)
+	}
+}
+
+func TestGetOrLoadCancellation(t *testing.T) {
+	c := New[int, int](10)
+	waiters := func(key int) int {
+		s := c.shards[0]
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if call, ok := s.calls[key]; ok {
+			return call.waiters
+		}
+		return 0
+	}
+
+	// One waiter giving up does not cancel the load for the others.
+	release := make(chan struct{})
+	loader := func(ctx context.Context, k int) (int, error) {
+		select {
+		case <-release:
+			return 1, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() {
+		_, err := c.GetOrLoad(ctx, 1, loader)
+		firstErr <- err
+	}()
+	second := make(chan int)
+	go func() {
+		v, _ := c.GetOrLoad(context.Background(), 1, loader)
+		second <- v
+	}()
+	for waiters(1) != 2 {
+		runtime.Gosched()
+	}
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled waiter to return context.Canceled, got %v", err)
+	}
+	close(release)
+	if v := <-second; v != 1 {
+		t.Fatalf("expected remaining waiter to get loaded value, got %d", v)
+	}
+
+	// The load is canceled once every waiter has given up.
+	loaderCanceled := make(chan struct{})
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		for waiters(2) != 1 {
+			runtime.Gosched()
+		}
+		cancel()
+	}()
+	_, err := c.GetOrLoad(ctx, 2, func(ctx context.Context, k int) (int, error) {
+		<-ctx.Done()
+		close(loaderCanceled)
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	<-loaderCanceled
+
+	// A call after every waiter gave up starts a new load rather than joining
+	// the canceled one, even if its loader has not returned yet.
+	stuck := make(chan struct{})
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		for waiters(3) != 1 {
+			runtime.Gosched()
+		}
+		cancel()
+	}()
+	_, err = c.GetOrLoad(ctx, 3, func(ctx context.Context, k int) (int, error) {
+		<-stuck
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	// The timeout only keeps a regression from hanging the test.
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	v, err := c.GetOrLoad(ctx, 3, func(ctx context.Context, k int) (int, error) {
+		return 5, nil
+	})
+	if err != nil || v != 5 {
+		t.Fatalf("expected a new load to return 5, got %d, %v", v, err)
+	}
+	close(stuck)
+}
@@ -0,0 +1,333 @@
+package lfu
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Option configures a Cache at construction. Options are passed to
+// NewWithOptions.
+type Option func(*options)
+
+type options struct {
+	shards int
+
+	// numBuckets is 0 unless set by WithBuckets. curve is the promotion
+	// probability of each bucket but the highest; it is derived from
+	// numBuckets and promoteBase unless set by WithPromotionCurve.
+	numBuckets  int
+	promoteBase float64
+	curve       []float64
+	randSource  func() rand.Source
+	seed        uint64
+	seeded      bool
+	hasher      any // Hasher[K], checked by NewWithOptions
+
+	ttl     time.Duration
+	onEvict any // func(K, V, EvictReason), checked by newLFUShard
+
+	costCapacity bool
+	weigher      any // func(K, V) int64, checked by newLFUShard
+
+	codec Codec
+
+	agingEvery int
+
+	preserveFrequency bool
+
+	victimSample int
+
+	strict bool
+
+	accessCounts bool
+	heavyHitters int
+
+	admission bool
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		shards:      1,
+		promoteBase: defaultPromoteBase,
+		codec:       GobCodec{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.curve == nil {
+		n := o.numBuckets
+		if n == 0 {
+			n = defaultNumBuckets
+		}
+		o.curve = make([]float64, n-1)
+		for i := range o.curve {
+			o.curve[i] = math.Pow(o.promoteBase, float64(i))
+		}
+	} else if o.numBuckets != 0 && o.numBuckets != len(o.curve)+1 {
+		panic(fmt.Sprintf("lfu: WithBuckets(%d) conflicts with a promotion curve for %d buckets", o.numBuckets, len(o.curve)+1))
+	}
+	return o
+}
+
+// WithShards splits the cache into up to n shards (stripes), each with its own
+// mutex, so concurrent operations on different keys can proceed in parallel;
+// eviction is local to each shard. The default is one shard. NewWithOptions
+// panics if n is less than 1.
+//
+// The sum of per-shard limits is at least size (often slightly more per shard
+// to absorb hash skew), so the cache may hold more than size entries under a
+// striped layout. For a cost-aware cache (see WithCostCapacity), size is split
+// the same way as a cost budget, with slack of at most an eighth of each
+// shard's share. When size is small relative to n, fewer shards are used so
+// each holds at least about 64 items on average.
+func WithShards(n int) Option {
+	return func(o *options) {
+		if n < 1 {
+			panic("lfu: numShards must be at least 1")
+		}
+		o.shards = n
+	}
+}
+
+// WithBuckets sets the number of frequency buckets, from 1 to 127; the default
+// is 4. More buckets distinguish more levels of popularity. With the default
+// geometric promotion curve, a Get promotes a key from bucket i to i+1 with
+// probability base^i (see WithPromotionBase), so each bucket takes roughly
+// 1/base times as many accesses to leave as the one below it.
+func WithBuckets(n int) Option {
+	return func(o *options) {
+		if n < 1 || n > maxNumBuckets {
+			panic(fmt.Sprintf("lfu: number of buckets must be between 1 and %d", maxNumBuckets))
+		}
+		o.numBuckets = n
+	}
+}
+
+// WithPromotionBase sets the base of the geometric promotion curve: a Get
+// promotes a key from bucket i to bucket i+1 with probability base^i, so keys
+// in bucket 0 are always promoted. The default is 0.01. A larger base lets
+// keys climb faster.
+func WithPromotionBase(base float64) Option {
+	return func(o *options) {
+		if !(base > 0 && base <= 1) {
+			panic("lfu: promotion base must be in (0, 1]")
+		}
+		o.promoteBase = base
+	}
+}
+
+// WithPromotionCurve sets the promotion probabilities directly: a Get promotes
+// a key from bucket i to bucket i+1 with probability curve[i]. The cache has
+// len(curve)+1 buckets; a conflicting WithBuckets makes NewWithOptions panic.
+// It overrides WithPromotionBase.
+func WithPromotionCurve(curve []float64) Option {
+	if len(curve) >= maxNumBuckets {
+		panic(fmt.Sprintf("lfu: promotion curve must have fewer than %d entries", maxNumBuckets))
+	}
+	for _, p := range curve {
+		if !(p >= 0 && p <= 1) {
+			panic("lfu: promotion probabilities must be in [0, 1]")
+		}
+	}
+	curve = append([]float64{}, curve...)
+	return func(o *options) {
+		o.curve = curve
+	}
+}
+
+// WithRandSource sets the source of randomness for probabilistic promotion.
+// newSource is called once per shard, and each source is used only under its
+// shard's mutex, so sources need not be safe for concurrent use. By default
+// each shard uses a source seeded from the current time.
+func WithRandSource(newSource func() rand.Source) Option {
+	return func(o *options) {
+		o.randSource = newSource
+	}
+}
+
+// WithSeed makes the cache deterministic: shard routing, the admission
+// filter's hashing, promotion, and the choice of victim within a bucket are
+// all derived from seed, so the same sequence of operations on caches with the
+// same seed and configuration leaves them in the same state. That holds in
+// other processes too, on machines of the same byte order, unless keys
+// contain pointers, channels, or unsafe pointers (directly or in an
+// interface): those are hashed by address, so their routing and admission
+// agree only within one process. It overrides WithRandSource. Expiration still
+// depends on the wall clock, so entries with a TTL can make runs diverge.
+// Seeded routing hashes keys with FNV-1a rather than maphash, which is slower
+// for long keys and, being predictable, should not be used with keys chosen by
+// an adversary.
+func WithSeed(seed int64) Option {
+	return func(o *options) {
+		o.seed = uint64(seed)
+		o.seeded = true
+	}
+}
+
+// WithHasher sets the function used to hash keys for shard routing and, with
+// WithAdmission, for the admission filter, in place of the built-in hashing.
+// It is useful for key types that contain interfaces, which the built-in
+// hashing formats with fmt, or to hash only the fields of a key that identify
+// it. The key type of fn must match the cache's; NewWithOptions panics
+// otherwise.
+func WithHasher[K comparable](fn Hasher[K]) Option {
+	return func(o *options) {
+		o.hasher = fn
+	}
+}
+
+// WithTTL sets the default time-to-live for entries added with Set. An entry
+// older than ttl misses on Get and Peek and is reclaimed before live entries
+// when its shard needs room. A ttl of zero or less (the default) means entries
+// added with Set do not expire. SetWithTTL overrides the default per entry.
+func WithTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.ttl = ttl
+	}
+}
+
+// WithOnEvict registers fn to be called for every entry that leaves the cache,
+// with the reason it left. fn is called after the shard's mutex is released,
+// so it may safely call back into the cache, but it runs synchronously on the
+// goroutine whose operation evicted the entry. The key and value types of fn
+// must match the cache's; NewWithOptions panics otherwise.
+func WithOnEvict[K comparable, V any](fn func(key K, value V, reason EvictReason)) Option {
+	return func(o *options) {
+		o.onEvict = fn
+	}
+}
+
+// WithCostCapacity makes the cache cost-aware: the size passed to
+// NewWithOptions is a total cost budget (for example, bytes) rather than an
+// entry count. Entries added with Set cost one unless a weigher is configured
+// with WithWeigher; SetWithCost sets an entry's cost explicitly. When adding
+// an entry would exceed its shard's budget, low-frequency entries are evicted
+// until it fits. An entry that costs more than its shard's whole budget is not
+// retained.
+func WithCostCapacity() Option {
+	return func(o *options) {
+		o.costCapacity = true
+	}
+}
+
+// WithWeigher makes the cache cost-aware, as WithCostCapacity does, and uses
+// fn to compute the cost of entries added with Set. fn is called without any
+// lock held. The key and value types of fn must match the cache's;
+// NewWithOptions panics otherwise.
+func WithWeigher[K comparable, V any](fn func(key K, value V) int64) Option {
+	return func(o *options) {
+		o.costCapacity = true
+		o.weigher = fn
+	}
+}
+
+// WithCodec sets the codec Snapshot and Restore use to write and read entries.
+// The default is GobCodec.
+func WithCodec(codec Codec) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
+
+// WithAging demotes every entry in a shard one frequency bucket after every n
+// Get and Set operations on that shard, in the spirit of LFU with dynamic
+// aging. Without aging, a key that reaches the highest bucket stays there until
+// it is removed or overwritten, so keys that were popular long ago can crowd out
+// keys that are popular now. Smaller n adapts faster to shifting popularity at
+// the cost of forgetting frequency sooner; a few times the shard's capacity is
+// a reasonable starting point. Each aging is O(n) in the shard's entries. An n
+// of zero or less (the default) disables aging.
+func WithAging(n int) Option {
+	return func(o *options) {
+		if n < 0 {
+			n = 0
+		}
+		o.agingEvery = n
+	}
+}
+
+// WithPreserveFrequency makes Set replace the value of an existing key in
+// place, as Update does, keeping the key's frequency bucket instead of
+// resetting it to the lowest bucket. The entry's deadline is still reset to
+// the cache's default TTL. Use it when keys are rewritten about as often as
+// they are read, so that refreshing a hot key does not make it the next
+// eviction candidate.
+func WithPreserveFrequency() Option {
+	return func(o *options) {
+		o.preserveFrequency = true
+	}
+}
+
+// WithVictimSample changes how a victim is chosen from the lowest non-empty
+// bucket. By default a shard evicts whichever key of that bucket map iteration
+// yields first, which may be a key added moments ago that has not yet had a
+// chance to be read and promoted. With WithVictimSample, the shard draws k
+// keys from the bucket at random and evicts the one that was added (or last
+// overwritten with Set) longest ago, approximating LRU order within the
+// bucket. Larger k approximates it more closely at the cost of more random
+// draws per eviction; 5 to 10 is usually enough. Tracking insertion order
+// costs about 24 bytes per entry. A k of zero or less (the default) disables
+// sampling.
+func WithVictimSample(k int) Option {
+	return func(o *options) {
+		if k < 0 {
+			k = 0
+		}
+		o.victimSample = k
+	}
+}
+
+// WithStrictCapacity makes size a hard limit on the whole cache: the total
+// number of entries, or their total cost with WithCostCapacity, never exceeds
+// size, at the cost of an atomic operation on a budget shared by all shards
+// for every added and removed entry. Without it, each shard of a sharded
+// cache has its own limit with some slack (see WithShards). A shard that
+// needs room evicts its own lowest-frequency entries first; if it has none, it
+// evicts from another shard that it can lock without waiting. If every other
+// shard is busy, the new entry is not retained and is reported to WithOnEvict
+// with EvictCapacity. Resize of a strict cache evicts only when the cache
+// holds more than the new size, taking from each shard in proportion to what
+// it holds until the whole cache fits; growing it never evicts.
+func WithStrictCapacity() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithAccessCounts makes each shard count the Get hits on each key since it
+// was added or last overwritten with Set, at a cost of about 16 bytes per
+// entry. HotKeys uses the counts to rank keys within a frequency bucket, and
+// Inspect reports them.
+func WithAccessCounts() Option {
+	return func(o *options) {
+		o.accessCounts = true
+	}
+}
+
+// WithHeavyHitters makes each shard track the k keys it is asked for most
+// often with a Space-Saving sketch, which HeavyHitters reports. The sketch
+// counts every Get, hit or miss, so it remembers hot keys after they are
+// evicted. Any key that accounts for more than 1/k of a shard's lookups is
+// guaranteed to be tracked. A k of zero or less (the default) disables the
+// sketch.
+func WithHeavyHitters(k int) Option {
+	return func(o *options) {
+		o.heavyHitters = k
+	}
+}
+
+// WithAdmission puts a TinyLFU admission filter in front of the cache. Each
+// shard records the keys of all Get operations, hits and misses alike, in a
+// count-min sketch fronted by a doorkeeper Bloom filter. When a shard is full,
+// a new key whose estimated frequency is lower than that of the entry it would
+// displace is rejected instead of added, so scans of keys that are used once
+// do not churn the cache. Rejected entries are reported to WithOnEvict with
+// EvictRejected. The sketch and doorkeeper take about three bytes per entry of
+// capacity.
+func WithAdmission() Option {
+	return func(o *options) {
+		o.admission = true
+	}
+}
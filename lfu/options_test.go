@@ -0,0 +1,105 @@
+package lfu
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	c := NewWithOptions[int, int](4096, WithShards(8), WithBuckets(6))
+	if len(c.shards) != 8 {
+		t.Fatalf("expected 8 shards, got %d", len(c.shards))
+	}
+	want := []float64{1, 0.01, 0.0001, 1e-6, 1e-8}
+	for i, p := range c.shards[0].promoteThreshold {
+		if d := p - want[i]; d > 1e-15 || d < -1e-15 {
+			t.Fatalf("expected geometric curve %v, got %v", want, c.shards[0].promoteThreshold)
+		}
+	}
+	if n := len(c.Stats().Buckets); n != 6 {
+		t.Fatalf("expected 6 buckets, got %d", n)
+	}
+}
+
+func TestNewShards(t *testing.T) {
+	// New and NewSharded keep their signatures, so they can be passed as
+	// constructors.
+	var newCache func(size int) *Cache[int, int] = New[int, int]
+	var newSharded func(size, numShards int) *Cache[int, int] = NewSharded[int, int]
+	if n := len(newCache(4096).shards); n != 1 {
+		t.Fatalf("expected New to use 1 shard, got %d", n)
+	}
+	if n := len(newSharded(4096, 8).shards); n != 8 {
+		t.Fatalf("expected NewSharded to use 8 shards, got %d", n)
+	}
+}
+
+func TestPromotionCurve(t *testing.T) {
+	// Every Get promotes, so a key's bucket counts its Gets up to the top.
+	c := NewWithOptions[int, int](10, WithPromotionCurve([]float64{1, 1, 1}))
+	c.Set(1, 1)
+	for i := 0; i < 2; i++ {
+		c.Get(1)
+	}
+	if got := c.Stats().Buckets; !reflect.DeepEqual(got, []int{0, 0, 1, 0}) {
+		t.Fatalf("expected key in bucket 2, got %v", got)
+	}
+	for i := 0; i < 10; i++ {
+		c.Get(1)
+	}
+	if got := c.Stats().Buckets; !reflect.DeepEqual(got, []int{0, 0, 0, 1}) {
+		t.Fatalf("expected key to stop at the top bucket, got %v", got)
+	}
+
+	// A zero probability caps how far keys climb.
+	c = NewWithOptions[int, int](10, WithPromotionCurve([]float64{1, 0}))
+	c.Set(1, 1)
+	for i := 0; i < 100; i++ {
+		c.Get(1)
+	}
+	if got := c.Stats().Buckets; !reflect.DeepEqual(got, []int{0, 1, 0}) {
+		t.Fatalf("expected key held in bucket 1, got %v", got)
+	}
+}
+
+func TestSingleBucket(t *testing.T) {
+	c := NewWithOptions[int, int](2, WithBuckets(1), WithAging(10))
+	for i := 0; i < 100; i++ {
+		c.Set(i, i)
+		c.Get(i)
+	}
+	if c.Len() != 2 {
+		t.Fatalf("expected Len 2, got %d", c.Len())
+	}
+}
+
+func TestWithRandSource(t *testing.T) {
+	calls := 0
+	NewWithOptions[int, int](4096, WithShards(4), WithRandSource(func() rand.Source {
+		calls++
+		return rand.NewSource(1)
+	}))
+	if calls != 4 {
+		t.Fatalf("expected one source per shard, got %d", calls)
+	}
+}
+
+func TestOptionValidation(t *testing.T) {
+	for name, fn := range map[string]func(){
+		"shards":         func() { NewWithOptions[int, int](10, WithShards(0)) },
+		"buckets":        func() { NewWithOptions[int, int](10, WithBuckets(128)) },
+		"base":           func() { NewWithOptions[int, int](10, WithPromotionBase(0)) },
+		"curve":          func() { NewWithOptions[int, int](10, WithPromotionCurve([]float64{1.5})) },
+		"curve conflict": func() { NewWithOptions[int, int](10, WithBuckets(3), WithPromotionCurve([]float64{1})) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected panic", name)
+				}
+			}()
+			fn()
+		}()
+	}
+}
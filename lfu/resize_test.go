@@ -0,0 +1,77 @@
+package lfu
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/tysonmote/cache/internal/split"
+)
+
+func TestResizeShrinkKeepsFrequentKeys(t *testing.T) {
+	c := New[int, int](100)
+	for i := 0; i < 100; i++ {
+		c.Set(i, i)
+	}
+	for i := 0; i < 10; i++ {
+		c.Get(i)
+	}
+	c.Resize(10)
+	if c.Len() != 10 {
+		t.Fatalf("expected Len 10 after shrinking, got %d", c.Len())
+	}
+	for i := 0; i < 10; i++ {
+		if _, ok := c.Peek(i); !ok {
+			t.Fatalf("expected frequently used key %d to survive shrinking", i)
+		}
+	}
+
+	c.Resize(50)
+	for i := 100; i < 140; i++ {
+		c.Set(i, i)
+	}
+	if c.Len() != 50 {
+		t.Fatalf("expected Len 50 after growing, got %d", c.Len())
+	}
+
+	c.Resize(0)
+	c.Set(1, 1)
+	if c.Len() != 0 {
+		t.Fatalf("expected Len 0 after resizing to 0, got %d", c.Len())
+	}
+}
+
+func TestResizeCost(t *testing.T) {
+	c := NewWithOptions[int, int](100, WithCostCapacity())
+	for i := 0; i < 10; i++ {
+		c.SetWithCost(i, i, 10)
+	}
+	c.Resize(35)
+	if c.Cost() != 30 {
+		t.Fatalf("expected cost 30 after shrinking, got %d", c.Cost())
+	}
+}
+
+func TestResizeConcurrent(t *testing.T) {
+	c := NewSharded[int, int](10_000, 8)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 10_000; i++ {
+				c.Set(i*4+g, i)
+				c.Get(i)
+			}
+		}(g)
+	}
+	for size := 10_000; size >= 1000; size -= 1000 {
+		c.Resize(size)
+	}
+	wg.Wait()
+	cap := split.Capacity(1000, len(c.shards), false)
+	for i, st := range c.ShardStats() {
+		if st.Len > cap {
+			t.Fatalf("shard %d holds %d entries, over its limit %d", i, st.Len, cap)
+		}
+	}
+}
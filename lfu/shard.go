@@ -1,15 +1,21 @@
 package lfu
 
 import (
-	"encoding/binary"
 	"fmt"
-	"hash/maphash"
-	"math"
 	"math/rand"
 	"sync"
 	"time"
+
+	"github.com/tysonmote/cache/internal/keyhash"
+	"github.com/tysonmote/cache/internal/sketch"
 )
 
+// nowNano returns the current time in unix nanoseconds. Tests replace it to
+// control expiration.
+var nowNano = func() int64 {
+	return time.Now().UnixNano()
+}
+
 // lfuShard is one stripe of a sharded cache: its own mutex, capacity, and LFU
 // bucket maps. Eviction is local to this shard only.
 type lfuShard[K comparable, V any] struct {
@@ -17,50 +23,230 @@ type lfuShard[K comparable, V any] struct {
 	mu  sync.Mutex
 	rng *rand.Rand
 
-	promoteThreshold [numBuckets]float64
+	// top is the highest bucket index. promoteThreshold[i] is the probability
+	// that a get promotes a key from bucket i to i+1.
+	top              int8
+	promoteThreshold []float64
 	index            map[K]int8
-	buckets          [numBuckets]map[K]V
+	buckets          []map[K]V
+
+	// order, when non-nil, lists the keys of each bucket, and pos holds each
+	// key's position in its list. Victims are then drawn from the list with
+	// rng instead of taken from map iteration, whose order Go randomizes, so a
+	// seeded shard evolves reproducibly.
+	order [][]K
+	pos   map[K]int32
+
+	// sample, if positive, is the number of keys of the lowest bucket drawn
+	// from order when choosing a victim; the one added longest ago, by the
+	// sequence numbers in added, is evicted (see WithVictimSample).
+	sample int
+	added  map[K]uint64
+	seq    uint64
+
+	// ttl is the default time-to-live for set. expiry holds the deadline (unix
+	// nanoseconds) of each entry that expires; it is nil until one does, so
+	// caches without expiration pay nothing beyond a nil check. deadlines
+	// orders the same deadlines, earliest first (see expireAt).
+	ttl       time.Duration
+	expiry    map[K]int64
+	deadlines deadlineHeap[K]
+
+	// onEvict, if set, is called for each entry that leaves the shard. Entries
+	// are queued in pending while mu is held and delivered by unlock.
+	onEvict func(K, V, EvictReason)
+	pending []evicted[K, V]
+
+	// admission, if set, is the TinyLFU frequency estimator consulted before a
+	// new key displaces a victim. hash computes the key hashes it records.
+	admission *sketch.TinyLFU
+	hash      func(K) uint64
+
+	// agingEvery is the number of get and set operations between agings, or 0
+	// if aging is disabled; ops counts operations since the last aging.
+	agingEvery int
+	ops        int
+
+	// counts holds the number of Get hits on each key since it was added (see
+	// WithAccessCounts), and heavy estimates the most looked-up keys (see
+	// WithHeavyHitters). Each is nil unless enabled.
+	counts map[K]uint64
+	heavy  *sketch.SpaceSaving[K]
+
+	// budget, peers, and next are set for a cache with WithStrictCapacity:
+	// budget is shared by all shards, peers are the cache's shards, and next
+	// is the peer steal tries first.
+	budget *budget
+	peers  []*lfuShard[K, V]
+	next   int
+
+	// preserve makes set replace an existing entry in place (see
+	// WithPreserveFrequency).
+	preserve bool
+
+	// stats holds the shard's activity counters. Len and Buckets are filled in
+	// only by snapshots.
+	stats Stats
+
+	// calls holds in-flight GetOrLoad loads by key. It is nil until the first
+	// load.
+	calls map[K]*loadCall[V]
+
+	// costs holds each entry's cost when the shard is cost-aware, in which case
+	// cap is a cost budget and used is the total cost of all entries. costs is
+	// nil when cap counts entries.
+	costs   map[K]int64
+	used    int64
+	weigher func(K, V) int64
+}
+
+type evicted[K comparable, V any] struct {
+	key    K
+	value  V
+	reason EvictReason
 }
 
-func newLFUShard[K comparable, V any](cap int) *lfuShard[K, V] {
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-	buckets := [numBuckets]map[K]V{}
+func newLFUShard[K comparable, V any](shard, cap int, o *options) *lfuShard[K, V] {
+	var src rand.Source
+	switch {
+	case o.seeded:
+		src = rand.NewSource(int64(keyhash.Mix64(o.seed + uint64(shard))))
+	case o.randSource != nil:
+		src = o.randSource()
+	default:
+		src = rand.NewSource(time.Now().UnixNano())
+	}
+	buckets := make([]map[K]V, len(o.curve)+1)
 	for i := range buckets {
 		buckets[i] = map[K]V{}
 	}
-	var th [numBuckets]float64
-	for i := int8(1); i < numBuckets; i++ {
-		th[i] = math.Pow(promoteBase, float64(i))
-	}
-	return &lfuShard[K, V]{
+	s := &lfuShard[K, V]{
 		cap:              cap,
-		rng:              rng,
-		promoteThreshold: th,
+		rng:              rand.New(src),
+		top:              int8(len(o.curve)),
+		promoteThreshold: o.curve,
 		index:            map[K]int8{},
 		buckets:          buckets,
+		ttl:              o.ttl,
+		agingEvery:       o.agingEvery,
+		preserve:         o.preserveFrequency,
+	}
+	if o.onEvict != nil {
+		fn, ok := o.onEvict.(func(K, V, EvictReason))
+		if !ok {
+			panic(fmt.Sprintf("lfu: WithOnEvict callback is %T, want %T", o.onEvict, fn))
+		}
+		s.onEvict = fn
+	}
+	if o.costCapacity {
+		s.costs = map[K]int64{}
+	}
+	if o.seeded || o.victimSample > 0 {
+		s.order = make([][]K, len(buckets))
+		s.pos = map[K]int32{}
+	}
+	if o.accessCounts {
+		s.counts = map[K]uint64{}
+	}
+	if o.heavyHitters > 0 {
+		s.heavy = sketch.NewSpaceSaving[K](o.heavyHitters)
+	}
+	if o.victimSample > 0 {
+		s.sample = o.victimSample
+		s.added = map[K]uint64{}
+	}
+	if o.weigher != nil {
+		fn, ok := o.weigher.(func(K, V) int64)
+		if !ok {
+			panic(fmt.Sprintf("lfu: WithWeigher function is %T, want %T", o.weigher, fn))
+		}
+		s.weigher = fn
+	}
+	return s
+}
+
+// weigh returns the cost of an entry for a cost-aware shard. It is called
+// without mu held since the weigher is user code.
+func (s *lfuShard[K, V]) weigh(key K, value V) int64 {
+	if s.weigher == nil {
+		return 1
+	}
+	return s.weigher(key, value)
+}
+
+// unlock releases mu and then delivers queued eviction callbacks, so callbacks
+// may call back into the cache.
+func (s *lfuShard[K, V]) unlock() {
+	if len(s.pending) == 0 {
+		s.mu.Unlock()
+		return
+	}
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	for _, e := range pending {
+		s.onEvict(e.key, e.value, e.reason)
+	}
+}
+
+// notify queues an eviction callback for delivery by unlock.
+func (s *lfuShard[K, V]) notify(key K, value V, reason EvictReason) {
+	if s.onEvict != nil {
+		s.pending = append(s.pending, evicted[K, V]{key, value, reason})
 	}
 }
 
 func (s *lfuShard[K, V]) get(key K) (v V, ok bool) {
 	s.mu.Lock()
-	i, ok := s.index[key]
+	v, ok = s.getLocked(key)
+	s.unlock()
+	return v, ok
+}
+
+func (s *lfuShard[K, V]) getLocked(key K) (v V, ok bool) {
+	if s.heavy != nil {
+		s.heavy.Add(key)
+	}
+	i, ok := s.lookupLocked(key)
 	if !ok {
-		s.mu.Unlock()
+		s.stats.Misses++
 		return v, false
 	}
+	s.stats.Hits++
+	if s.counts != nil {
+		s.counts[key]++
+	}
 	v = s.buckets[i][key]
-	if i == 0 || (i < maxBucketIndex && s.rng.Float64() < s.promoteThreshold[i]) {
+	s.touch(i, key)
+	return v, true
+}
+
+// lookupLocked records an access to key and returns its bucket if it is live.
+func (s *lfuShard[K, V]) lookupLocked(key K) (i int8, ok bool) {
+	s.tick()
+	if s.admission != nil {
+		s.admission.Record(s.hash(key))
+	}
+	i, ok = s.index[key]
+	if !ok || s.expiredLocked(i, key) {
+		return 0, false
+	}
+	return i, true
+}
+
+// touch promotes key, which must be in bucket i, with the probability given by
+// the promotion curve.
+func (s *lfuShard[K, V]) touch(i int8, key K) {
+	if i < s.top && (s.promoteThreshold[i] >= 1 || s.rng.Float64() < s.promoteThreshold[i]) {
 		s.promote(i, key)
 	}
-	s.mu.Unlock()
-	return v, true
 }
 
 func (s *lfuShard[K, V]) peek(key K) (v V, ok bool) {
 	s.mu.Lock()
 	i, ok := s.index[key]
-	if !ok {
-		s.mu.Unlock()
+	if !ok || s.expiredLocked(i, key) {
+		s.unlock()
 		return v, false
 	}
 	v = s.buckets[i][key]
@@ -72,129 +258,402 @@ func (s *lfuShard[K, V]) promote(i int8, key K) {
 	s.buckets[i+1][key] = s.buckets[i][key]
 	s.index[key] = i + 1
 	delete(s.buckets[i], key)
+	if s.order != nil {
+		s.unlist(i, key)
+		s.list(i+1, key)
+	}
+}
+
+// list appends key to bucket b's key list.
+func (s *lfuShard[K, V]) list(b int8, key K) {
+	s.pos[key] = int32(len(s.order[b]))
+	s.order[b] = append(s.order[b], key)
+}
+
+// unlist removes key from bucket b's key list by moving the last key into its
+// place.
+func (s *lfuShard[K, V]) unlist(b int8, key K) {
+	keys := s.order[b]
+	p, last := s.pos[key], len(keys)-1
+	moved := keys[last]
+	keys[p] = moved
+	s.pos[moved] = p
+	var zero K
+	keys[last] = zero
+	s.order[b] = keys[:last]
+	delete(s.pos, key)
 }
 
-func (s *lfuShard[K, V]) set(key K, value V) {
+// tick counts an operation and ages the shard every agingEvery operations.
+func (s *lfuShard[K, V]) tick() {
+	if s.agingEvery == 0 {
+		return
+	}
+	s.ops++
+	if s.ops >= s.agingEvery {
+		s.ops = 0
+		s.age()
+	}
+}
+
+// age demotes every entry one bucket, merging bucket 1 into bucket 0, so keys
+// that were popular long ago must keep being accessed to stay ahead of newer
+// keys. It is O(n) in the shard's entries, amortized over agingEvery
+// operations.
+func (s *lfuShard[K, V]) age() {
+	if len(s.buckets) < 2 {
+		return
+	}
+	low, high := s.buckets[0], s.buckets[1]
+	if len(high) > len(low) {
+		low, high = high, low
+	}
+	for k, v := range high {
+		low[k] = v
+	}
+	copy(s.buckets[1:], s.buckets[2:])
+	s.buckets[0] = low
+	s.buckets[len(s.buckets)-1] = map[K]V{}
+	for k, i := range s.index {
+		if i > 0 {
+			s.index[k] = i - 1
+		}
+	}
+	if s.order != nil {
+		for _, k := range s.order[1] {
+			s.list(0, k)
+		}
+		copy(s.order[1:], s.order[2:])
+		s.order[len(s.order)-1] = nil
+	}
+	s.stats.Agings++
+}
+
+// set adds or overwrites key. A ttl of zero or less means the entry does not
+// expire. cost is ignored unless the shard is cost-aware; an entry whose cost
+// exceeds the shard's budget is not retained.
+func (s *lfuShard[K, V]) set(key K, value V, ttl time.Duration, cost int64) {
 	s.mu.Lock()
-	if s.cap == 0 {
+	s.setLocked(key, value, ttl, cost)
+	s.unlock()
+}
+
+func (s *lfuShard[K, V]) setLocked(key K, value V, ttl time.Duration, cost int64) {
+	if cost < 0 {
+		cost = 0
+	}
+	s.tick()
+	s.stats.Sets++
+	if s.cap == 0 || (s.costs != nil && cost > int64(s.cap)) {
 		if i, ok := s.index[key]; ok {
-			delete(s.index, key)
-			delete(s.buckets[i], key)
+			s.stats.Overwrites++
+			s.drop(i, key, EvictReplaced)
 		}
-		s.mu.Unlock()
+		s.stats.Evictions++
+		s.notify(key, value, EvictCapacity)
 		return
 	}
-	if i, ok := s.index[key]; ok {
-		s.reset(i, key, value)
-		s.mu.Unlock()
-		return
+	overwrite := false
+	if i, ok := s.index[key]; ok && !s.expiredLocked(i, key) {
+		s.stats.Overwrites++
+		if s.preserve {
+			s.replaceLocked(i, key, value, cost)
+			if _, ok := s.index[key]; ok {
+				s.setExpiry(key, ttl)
+			}
+			return
+		}
+		if s.costs == nil {
+			s.reset(i, key, value)
+			s.setExpiry(key, ttl)
+			return
+		}
+		// A cost change may require evictions, which must not pick key itself,
+		// so a cost-aware overwrite removes the old entry and re-adds it. The
+		// key was already admitted, so the filter is not consulted again.
+		s.drop(i, key, EvictReplaced)
+		overwrite = true
 	}
-	if len(s.index) == s.cap {
-		s.evict()
+	if len(s.deadlines) > 0 {
+		s.reapExpired(nowNano())
+	}
+	for s.full(cost) {
+		i, victim, ok := s.victim()
+		if !ok {
+			// Only a strict shard can be full while empty; the budget is held
+			// by other shards.
+			if !s.steal() {
+				s.stats.Evictions++
+				s.notify(key, value, EvictCapacity)
+				return
+			}
+			continue
+		}
+		if s.admission != nil && !overwrite && !s.admission.Admit(s.hash(key), s.hash(victim)) {
+			s.stats.Rejections++
+			s.notify(key, value, EvictRejected)
+			return
+		}
+		s.drop(i, victim, EvictCapacity)
 	}
 	s.add(key, value)
-	s.mu.Unlock()
+	s.setExpiry(key, ttl)
+	if s.costs != nil {
+		s.costs[key] = cost
+		s.used += cost
+	}
+}
+
+// full reports whether an entry of the given cost must evict another entry to
+// fit. In a strict cache, a false result means the entry's cost has been
+// reserved from the shared budget, and the caller must add the entry.
+func (s *lfuShard[K, V]) full(cost int64) bool {
+	if s.budget != nil {
+		return !s.budget.reserve(s.charge(cost))
+	}
+	if s.costs == nil {
+		return len(s.index) >= s.cap
+	}
+	return len(s.index) > 0 && s.used+cost > int64(s.cap)
 }
 
 func (s *lfuShard[K, V]) reset(i int8, key K, value V) {
+	if s.onEvict != nil {
+		s.notify(key, s.buckets[i][key], EvictReplaced)
+	}
 	s.buckets[0][key] = value
+	if s.counts != nil {
+		delete(s.counts, key)
+	}
+	if s.added != nil {
+		s.seq++
+		s.added[key] = s.seq
+	}
 	if i > 0 {
 		delete(s.buckets[i], key)
 		s.index[key] = 0
+		if s.order != nil {
+			s.unlist(i, key)
+			s.list(0, key)
+		}
 	}
 }
 
 func (s *lfuShard[K, V]) add(k K, v V) {
-	s.buckets[0][k] = v
-	s.index[k] = 0
+	s.insert(0, k, v)
+}
+
+// insert adds k, which must not be present, to bucket b.
+func (s *lfuShard[K, V]) insert(b int8, k K, v V) {
+	s.buckets[b][k] = v
+	s.index[k] = b
+	if s.order != nil {
+		s.list(b, k)
+	}
+	if s.added != nil {
+		s.seq++
+		s.added[k] = s.seq
+	}
 }
 
-func (s *lfuShard[K, V]) evict() {
-	for _, bucket := range s.buckets {
+// evict frees room by deleting every expired entry or, if none has expired,
+// the victim. It reports whether any entry was deleted.
+func (s *lfuShard[K, V]) evict() bool {
+	if s.reapDue() {
+		return true
+	}
+	i, k, ok := s.victim()
+	if ok {
+		s.drop(i, k, EvictCapacity)
+	}
+	return ok
+}
+
+// victim returns the entry evict would remove, from the lowest non-empty
+// bucket, and its bucket.
+func (s *lfuShard[K, V]) victim() (i int8, key K, ok bool) {
+	if s.order != nil {
+		for i, keys := range s.order {
+			if len(keys) == 0 {
+				continue
+			}
+			key = keys[s.rng.Intn(len(keys))]
+			for j := 1; j < s.sample; j++ {
+				if k := keys[s.rng.Intn(len(keys))]; s.added[k] < s.added[key] {
+					key = k
+				}
+			}
+			return int8(i), key, true
+		}
+		return 0, key, false
+	}
+	for i, bucket := range s.buckets {
 		for k := range bucket {
-			delete(s.index, k)
-			delete(bucket, k)
-			return
+			return int8(i), k, true
+		}
+	}
+	return 0, key, false
+}
+
+// drop deletes key, which must be in bucket i, and queues its eviction
+// callback.
+func (s *lfuShard[K, V]) drop(i int8, key K, reason EvictReason) {
+	switch reason {
+	case EvictCapacity:
+		s.stats.Evictions++
+	case EvictRemoved:
+		s.stats.Removals++
+	case EvictExpired:
+		s.stats.Expirations++
+	}
+	if s.onEvict != nil {
+		s.notify(key, s.buckets[i][key], reason)
+	}
+	s.delete(i, key)
+}
+
+// delete removes key, which must be in bucket i, from all shard maps.
+func (s *lfuShard[K, V]) delete(i int8, key K) {
+	delete(s.index, key)
+	delete(s.buckets[i], key)
+	if s.order != nil {
+		s.unlist(i, key)
+	}
+	if s.added != nil {
+		delete(s.added, key)
+	}
+	if s.counts != nil {
+		delete(s.counts, key)
+	}
+	if s.budget != nil {
+		s.budget.release(s.charge(s.costs[key]))
+	}
+	if s.expiry != nil {
+		delete(s.expiry, key)
+	}
+	if s.costs != nil {
+		s.used -= s.costs[key]
+		delete(s.costs, key)
+	}
+}
+
+func (s *lfuShard[K, V]) setExpiry(key K, ttl time.Duration) {
+	if ttl <= 0 {
+		if s.expiry != nil {
+			delete(s.expiry, key)
 		}
+		return
+	}
+	s.expireAt(key, nowNano()+int64(ttl))
+}
+
+// expiredLocked reports whether key, which must be in bucket i, has expired.
+// An expired key is deleted so it stops occupying capacity.
+func (s *lfuShard[K, V]) expiredLocked(i int8, key K) bool {
+	if len(s.expiry) == 0 {
+		return false
+	}
+	deadline, ok := s.expiry[key]
+	if !ok || nowNano() < deadline {
+		return false
 	}
+	s.drop(i, key, EvictExpired)
+	return true
+}
+
+func (s *lfuShard[K, V]) deleteExpired() int {
+	s.mu.Lock()
+	n := s.reapExpired(nowNano())
+	s.unlock()
+	return n
 }
 
 func (s *lfuShard[K, V]) remove(key K) bool {
 	s.mu.Lock()
-	if i, ok := s.index[key]; ok {
-		delete(s.index, key)
-		delete(s.buckets[i], key)
-		s.mu.Unlock()
+	ok := s.removeLocked(key)
+	s.unlock()
+	return ok
+}
+
+func (s *lfuShard[K, V]) removeLocked(key K) bool {
+	if i, ok := s.index[key]; ok && !s.expiredLocked(i, key) {
+		s.drop(i, key, EvictRemoved)
 		return true
 	}
-	s.mu.Unlock()
 	return false
 }
 
 func (s *lfuShard[K, V]) clear() {
 	s.mu.Lock()
+	if s.budget != nil {
+		s.budget.release(s.costLocked())
+	}
+	if s.onEvict != nil {
+		for _, bucket := range s.buckets {
+			for k, v := range bucket {
+				s.notify(k, v, EvictCleared)
+			}
+		}
+	}
 	s.index = map[K]int8{}
 	for i := range s.buckets {
 		s.buckets[i] = map[K]V{}
 	}
-	s.mu.Unlock()
+	if s.order != nil {
+		s.order = make([][]K, len(s.buckets))
+		s.pos = map[K]int32{}
+	}
+	if s.added != nil {
+		s.added = map[K]uint64{}
+	}
+	if s.counts != nil {
+		s.counts = map[K]uint64{}
+	}
+	s.expiry, s.deadlines = nil, nil
+	if s.costs != nil {
+		s.costs = map[K]int64{}
+		s.used = 0
+	}
+	s.unlock()
+}
+
+func (s *lfuShard[K, V]) resize(cap int) {
+	s.mu.Lock()
+	s.cap = cap
+	for len(s.index) > 0 && s.overLocked() {
+		s.evict()
+	}
+	s.unlock()
+}
+
+// overLocked reports whether the shard holds more than its capacity.
+func (s *lfuShard[K, V]) overLocked() bool {
+	if s.costs == nil {
+		return len(s.index) > s.cap
+	}
+	return s.used > int64(s.cap)
 }
 
 func (s *lfuShard[K, V]) lenLocked() int {
 	return len(s.index)
 }
 
-func writeKey[K comparable](h *maphash.Hash, key K) {
-	switch k := any(key).(type) {
-	case int:
-		var b [8]byte
-		binary.LittleEndian.PutUint64(b[:], uint64(int64(k)))
-		h.Write(b[:])
-	case int8:
-		h.WriteByte(byte(k))
-	case int16:
-		var b [2]byte
-		binary.LittleEndian.PutUint16(b[:], uint16(k))
-		h.Write(b[:])
-	case int32:
-		var b [4]byte
-		binary.LittleEndian.PutUint32(b[:], uint32(k))
-		h.Write(b[:])
-	case int64:
-		var b [8]byte
-		binary.LittleEndian.PutUint64(b[:], uint64(k))
-		h.Write(b[:])
-	case uint:
-		var b [8]byte
-		binary.LittleEndian.PutUint64(b[:], uint64(k))
-		h.Write(b[:])
-	case uint8:
-		h.WriteByte(k)
-	case uint16:
-		var b [2]byte
-		binary.LittleEndian.PutUint16(b[:], k)
-		h.Write(b[:])
-	case uint32:
-		var b [4]byte
-		binary.LittleEndian.PutUint32(b[:], k)
-		h.Write(b[:])
-	case uint64:
-		var b [8]byte
-		binary.LittleEndian.PutUint64(b[:], k)
-		h.Write(b[:])
-	case uintptr:
-		var b [8]byte
-		binary.LittleEndian.PutUint64(b[:], uint64(k))
-		h.Write(b[:])
-	case string:
-		h.WriteString(k)
-	case bool:
-		if k {
-			h.WriteByte(1)
-		} else {
-			h.WriteByte(0)
-		}
-	default:
-		h.WriteString(fmt.Sprintf("%T:%v", key, key))
+// snapshot returns a copy of the shard's counters with occupancy filled in.
+func (s *lfuShard[K, V]) snapshot() Stats {
+	s.mu.Lock()
+	st := s.stats
+	st.Len = len(s.index)
+	st.Buckets = make([]int, len(s.buckets))
+	for i, bucket := range s.buckets {
+		st.Buckets[i] = len(bucket)
+	}
+	s.mu.Unlock()
+	return st
+}
+
+func (s *lfuShard[K, V]) costLocked() int64 {
+	if s.costs == nil {
+		return int64(len(s.index))
 	}
+	return s.used
 }
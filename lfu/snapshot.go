@@ -0,0 +1,220 @@
+package lfu
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// snapshotVersion identifies the layout written by Snapshot.
+const snapshotVersion = 1
+
+// Encoder writes values to a snapshot stream. *gob.Encoder and *json.Encoder
+// implement it.
+type Encoder interface {
+	Encode(v any) error
+}
+
+// Decoder reads values from a snapshot stream. *gob.Decoder and *json.Decoder
+// implement it.
+type Decoder interface {
+	Decode(v any) error
+}
+
+// Codec creates the encoder and decoder used by Snapshot and Restore. The
+// values passed to them are exported structs holding the cache's keys and
+// values, so any codec that can round-trip K and V works.
+type Codec interface {
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+// GobCodec is a Codec using encoding/gob. It is the default.
+type GobCodec struct{}
+
+func (GobCodec) NewEncoder(w io.Writer) Encoder { return gob.NewEncoder(w) }
+func (GobCodec) NewDecoder(r io.Reader) Decoder { return gob.NewDecoder(r) }
+
+type snapshotHeader struct {
+	Version  int
+	Weighted bool
+}
+
+// snapshotEntry is one entry in a snapshot. Bucket is the entry's frequency
+// bucket, Expires its deadline in unix nanoseconds (0 if none), and Cost its
+// cost if the cache was cost-aware.
+type snapshotEntry[K comparable, V any] struct {
+	Key     K
+	Value   V
+	Bucket  int8
+	Expires int64
+	Cost    int64
+}
+
+// Snapshot writes the cache's live entries to w using the cache's codec (see
+// WithCodec), together with each entry's frequency bucket, deadline, and cost,
+// so Restore can rebuild the cache with its frequency information intact.
+//
+// Shards are copied one at a time under their own mutex and encoded without
+// any lock held, with the same per-shard consistency as Range.
+func (c *Cache[K, V]) Snapshot(w io.Writer) error {
+	enc := c.codec.NewEncoder(w)
+	if err := enc.Encode(snapshotHeader{Version: snapshotVersion, Weighted: c.weighted}); err != nil {
+		return err
+	}
+	var buf []snapshotEntry[K, V]
+	for _, s := range c.shards {
+		buf = s.appendSnapshot(buf[:0])
+		if len(buf) == 0 {
+			continue
+		}
+		// Each shard is written as a count followed by its entries; a count of
+		// zero ends the stream.
+		if err := enc.Encode(len(buf)); err != nil {
+			return err
+		}
+		for i := range buf {
+			if err := enc.Encode(&buf[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return enc.Encode(0)
+}
+
+// Restore reads entries written by Snapshot from r and adds them to the cache,
+// keeping each entry's frequency bucket and deadline. The cache may have a
+// different size, shard count, or number of buckets than the one that wrote
+// the snapshot: entries are routed to this cache's shards, buckets beyond this
+// cache's highest are clamped to it, and expired entries are skipped. When a
+// shard is full, a restored entry only displaces an entry in a lower bucket,
+// so restoring into a smaller cache keeps the most frequently used entries.
+//
+// Restored entries overwrite existing entries with the same key. Restore may
+// be called concurrently with other operations.
+func (c *Cache[K, V]) Restore(r io.Reader) error {
+	dec := c.codec.NewDecoder(r)
+	var h snapshotHeader
+	if err := dec.Decode(&h); err != nil {
+		return err
+	}
+	if h.Version != snapshotVersion {
+		return fmt.Errorf("lfu: unsupported snapshot version %d", h.Version)
+	}
+	for {
+		var n int
+		if err := dec.Decode(&n); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+		for i := 0; i < n; i++ {
+			var e snapshotEntry[K, V]
+			if err := dec.Decode(&e); err != nil {
+				if errors.Is(err, io.EOF) {
+					err = io.ErrUnexpectedEOF
+				}
+				return err
+			}
+			if e.Expires != 0 && nowNano() >= e.Expires {
+				continue
+			}
+			s := c.shards[c.shardIndex(e.Key)]
+			cost := e.Cost
+			if !h.Weighted {
+				cost = s.weigh(e.Key, e.Value)
+			}
+			s.mu.Lock()
+			s.restoreLocked(&e, cost)
+			s.unlock()
+		}
+	}
+}
+
+func (s *lfuShard[K, V]) appendSnapshot(dst []snapshotEntry[K, V]) []snapshotEntry[K, V] {
+	s.mu.Lock()
+	now := nowNano()
+	// Most frequently used first, so a smaller cache restoring this stream
+	// fills with hot entries before cold ones.
+	for i := len(s.buckets) - 1; i >= 0; i-- {
+		add := func(k K, v V) {
+			if s.expiredAt(k, now) {
+				return
+			}
+			e := snapshotEntry[K, V]{Key: k, Value: v, Bucket: int8(i)}
+			if s.expiry != nil {
+				e.Expires = s.expiry[k]
+			}
+			if s.costs != nil {
+				e.Cost = s.costs[k]
+			}
+			dst = append(dst, e)
+		}
+		// A seeded shard writes each bucket in a reproducible order.
+		if s.order != nil {
+			for _, k := range s.order[i] {
+				add(k, s.buckets[i][k])
+			}
+			continue
+		}
+		for k, v := range s.buckets[i] {
+			add(k, v)
+		}
+	}
+	s.mu.Unlock()
+	return dst
+}
+
+// restoreLocked adds a snapshot entry in its recorded bucket, evicting only
+// entries from lower buckets to make room.
+func (s *lfuShard[K, V]) restoreLocked(e *snapshotEntry[K, V], cost int64) {
+	if cost < 0 {
+		cost = 0
+	}
+	if i, ok := s.index[e.Key]; ok {
+		s.drop(i, e.Key, EvictReplaced)
+	}
+	b := e.Bucket
+	if top := int8(len(s.buckets) - 1); b > top {
+		b = top
+	} else if b < 0 {
+		b = 0
+	}
+	if s.cap == 0 || (s.costs != nil && cost > int64(s.cap)) {
+		s.stats.Evictions++
+		s.notify(e.Key, e.Value, EvictCapacity)
+		return
+	}
+	for s.full(cost) {
+		if s.lowestBucket() >= b {
+			s.stats.Evictions++
+			s.notify(e.Key, e.Value, EvictCapacity)
+			return
+		}
+		s.evict()
+	}
+	s.insert(b, e.Key, e.Value)
+	if e.Expires != 0 {
+		s.expireAt(e.Key, e.Expires)
+	}
+	if s.costs != nil {
+		s.costs[e.Key] = cost
+		s.used += cost
+	}
+}
+
+// lowestBucket returns the index of the lowest non-empty bucket, or the number
+// of buckets if the shard is empty.
+func (s *lfuShard[K, V]) lowestBucket() int8 {
+	for i, bucket := range s.buckets {
+		if len(bucket) > 0 {
+			return int8(i)
+		}
+	}
+	return int8(len(s.buckets))
+}
@@ -0,0 +1,113 @@
+package lfu
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	advance := fakeClock(t)
+	src := NewSharded[string, int](4096, 8)
+	for i := 0; i < 200; i++ {
+		k := string(rune('a'+i%26)) + string(rune('0'+i/26))
+		src.Set(k, i)
+		if i%2 == 0 {
+			src.Get(k)
+		}
+	}
+	src.SetWithTTL("ttl", 1, time.Minute)
+	src.SetWithTTL("gone", 1, time.Second)
+	advance(time.Second)
+
+	var buf bytes.Buffer
+	if err := src.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := New[string, int](1000)
+	if err := dst.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Len() != 201 {
+		t.Fatalf("expected 201 live entries, got %d", dst.Len())
+	}
+	if got := dst.Stats().Buckets; !reflect.DeepEqual(got, []int{101, 100, 0, 0}) {
+		t.Fatalf("expected buckets to survive restore, got %v", got)
+	}
+	src.Range(func(k string, v int) bool {
+		if got, ok := dst.Peek(k); !ok || got != v {
+			t.Fatalf("expected %q=%d after restore, got %d, %v", k, v, got, ok)
+		}
+		return true
+	})
+	advance(time.Minute)
+	if _, ok := dst.Get("ttl"); ok {
+		t.Fatal("expected restored deadline to apply")
+	}
+}
+
+func TestRestoreIntoSmallerCacheKeepsFrequentEntries(t *testing.T) {
+	src := New[int, int](100)
+	for i := 0; i < 100; i++ {
+		src.Set(i, i)
+	}
+	for i := 0; i < 10; i++ {
+		src.Get(i)
+	}
+	var buf bytes.Buffer
+	if err := src.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	dst := New[int, int](10)
+	if err := dst.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, ok := dst.Peek(i); !ok {
+			t.Fatalf("expected frequently used key %d to be restored", i)
+		}
+	}
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) NewEncoder(w io.Writer) Encoder { return json.NewEncoder(w) }
+func (jsonCodec) NewDecoder(r io.Reader) Decoder { return json.NewDecoder(r) }
+
+func TestSnapshotCodec(t *testing.T) {
+	src := NewWithOptions[int, string](10, WithCodec(jsonCodec{}))
+	src.Set(1, "one")
+	var buf bytes.Buffer
+	if err := src.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"Value":"one"`)) {
+		t.Fatalf("expected JSON snapshot, got %q", buf.String())
+	}
+	dst := NewWithOptions[int, string](10, WithCodec(jsonCodec{}))
+	if err := dst.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := dst.Get(1); !ok || v != "one" {
+		t.Fatalf("expected restored value, got %q, %v", v, ok)
+	}
+}
+
+func TestRestoreTruncated(t *testing.T) {
+	src := New[int, int](10)
+	src.Set(1, 1)
+	src.Set(2, 2)
+	var buf bytes.Buffer
+	if err := src.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	truncated := bytes.NewReader(buf.Bytes()[:buf.Len()-4])
+	if err := New[int, int](10).Restore(truncated); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
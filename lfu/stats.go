@@ -0,0 +1,143 @@
+package lfu
+
+import "time"
+
+// Stats is a snapshot of a cache's activity counters and occupancy. Counters
+// are cumulative since the cache was created; Clear does not reset them.
+type Stats struct {
+	// Hits and Misses count Get and GetOrLoad lookups. Peek is not counted.
+	Hits   uint64
+	Misses uint64
+	// Sets counts calls that add or overwrite an entry, and Overwrites the
+	// subset of those that replaced a live entry.
+	Sets       uint64
+	Overwrites uint64
+	// Evictions counts entries evicted (or not retained) for lack of capacity.
+	Evictions uint64
+	// Rejections counts new entries turned away by the admission policy.
+	Rejections uint64
+	// Expirations counts expired entries that were reclaimed.
+	Expirations uint64
+	// Removals counts entries deleted by Remove.
+	Removals uint64
+	// Agings counts how many times entries were demoted by WithAging.
+	Agings uint64
+
+	// Len is the number of entries, and Buckets[i] the number of entries in
+	// frequency bucket i, lowest first.
+	Len     int
+	Buckets []int
+}
+
+// HitRatio returns Hits / (Hits + Misses), or 0 if there were no lookups.
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+func (s *Stats) add(o Stats) {
+	s.Hits += o.Hits
+	s.Misses += o.Misses
+	s.Sets += o.Sets
+	s.Overwrites += o.Overwrites
+	s.Evictions += o.Evictions
+	s.Rejections += o.Rejections
+	s.Expirations += o.Expirations
+	s.Removals += o.Removals
+	s.Agings += o.Agings
+	s.Len += o.Len
+	if s.Buckets == nil {
+		s.Buckets = make([]int, len(o.Buckets))
+	}
+	for i, n := range o.Buckets {
+		s.Buckets[i] += n
+	}
+}
+
+// Stats returns counters and occupancy aggregated across all shards. Counters
+// are kept per shard under the shard's own mutex, so recording them adds no
+// cross-shard contention. Shards are read one at a time, so the snapshot is
+// not atomic across shards.
+func (c *Cache[K, V]) Stats() Stats {
+	var st Stats
+	for _, s := range c.shards {
+		st.add(s.snapshot())
+	}
+	return st
+}
+
+// ShardStats returns a Stats snapshot for each shard, in shard order.
+func (c *Cache[K, V]) ShardStats() []Stats {
+	st := make([]Stats, len(c.shards))
+	for i, s := range c.shards {
+		st[i] = s.snapshot()
+	}
+	return st
+}
+
+// KeyInfo describes where a key sits in the cache's frequency structure. See
+// Inspect.
+type KeyInfo struct {
+	// Shard is the index of the key's shard, as in ShardStats.
+	Shard int
+	// Bucket is the key's frequency bucket, from 0 (the eviction candidates)
+	// up to the number of buckets minus one.
+	Bucket int
+	// Accesses is the expected number of Gets, per the promotion curve, that
+	// take a newly added key to Bucket: the sum of 1/p over the promotion
+	// probabilities p of the buckets below it. It is infinite if one of them
+	// is 0. It is an estimate of the key's access count, not a count; Update,
+	// aging, and Restore move keys between buckets without Gets.
+	Accesses float64
+	// Expires is the key's deadline, or the zero Time if it does not expire.
+	Expires time.Time
+	// Count is the number of Get hits on the key since it was added, if the
+	// cache was created with WithAccessCounts, and 0 otherwise.
+	Count uint64
+}
+
+// Inspect returns where key sits in the cache's frequency structure, or false
+// if it is not present. Like Peek, it does not count as an access.
+func (c *Cache[K, V]) Inspect(key K) (info KeyInfo, ok bool) {
+	i := c.shardIndex(key)
+	info, ok = c.shards[i].inspect(key)
+	info.Shard = i
+	return info, ok
+}
+
+// Histogram returns the number of entries in each frequency bucket of each
+// shard, lowest bucket first, in shard order. It is the Buckets field of
+// ShardStats without the counters.
+func (c *Cache[K, V]) Histogram() [][]int {
+	h := make([][]int, len(c.shards))
+	for i, s := range c.shards {
+		s.mu.Lock()
+		h[i] = make([]int, len(s.buckets))
+		for j, bucket := range s.buckets {
+			h[i][j] = len(bucket)
+		}
+		s.mu.Unlock()
+	}
+	return h
+}
+
+func (s *lfuShard[K, V]) inspect(key K) (info KeyInfo, ok bool) {
+	s.mu.Lock()
+	defer s.unlock()
+	b, ok := s.index[key]
+	if !ok || s.expiredLocked(b, key) {
+		return info, false
+	}
+	info.Bucket = int(b)
+	for _, p := range s.promoteThreshold[:b] {
+		info.Accesses += 1 / p
+	}
+	if deadline, ok := s.expiry[key]; ok {
+		info.Expires = time.Unix(0, deadline)
+	}
+	info.Count = s.counts[key]
+	return info, true
+}
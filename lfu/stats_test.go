@@ -0,0 +1,102 @@
+package lfu
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	advance := fakeClock(t)
+	c := New[int, int](2)
+	c.Set(1, 1)
+	c.Set(1, 2)
+	c.Get(1)
+	c.Get(2)
+	c.Peek(1)
+	c.Set(2, 2)
+	c.Set(3, 3)
+	c.Remove(3)
+	c.SetWithTTL(4, 4, time.Second)
+	advance(time.Second)
+	c.Get(4)
+
+	want := Stats{
+		Hits:        1,
+		Misses:      2,
+		Sets:        5,
+		Overwrites:  1,
+		Evictions:   1,
+		Expirations: 1,
+		Removals:    1,
+	}
+	got := c.Stats()
+	got.Len, got.Buckets = 0, nil
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	if r := c.Stats().HitRatio(); r != 1.0/3 {
+		t.Fatalf("expected hit ratio 1/3, got %v", r)
+	}
+}
+
+func TestShardStatsOccupancy(t *testing.T) {
+	c := NewSharded[int, int](1024, 4)
+	for i := 0; i < 100; i++ {
+		c.Set(i, i)
+		c.Get(i)
+	}
+	shards := c.ShardStats()
+	if len(shards) != 4 {
+		t.Fatalf("expected 4 shards, got %d", len(shards))
+	}
+	var hits uint64
+	for _, st := range shards {
+		hits += st.Hits
+	}
+	st := c.Stats()
+	if hits != 100 || st.Hits != 100 {
+		t.Fatalf("expected 100 hits in total, got %d per shard and %d aggregated", hits, st.Hits)
+	}
+	if st.Len != 100 || len(st.Buckets) != defaultNumBuckets || st.Buckets[1] != 100 {
+		t.Fatalf("expected 100 entries in bucket 1 after one Get each, got Len %d Buckets %v", st.Len, st.Buckets)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	fakeClock(t)
+	deadline := time.Unix(0, nowNano()).Add(time.Second)
+	c := NewWithOptions[int, int](1024, WithShards(4), WithPromotionCurve([]float64{1, 0.5, 0}))
+	if _, ok := c.Inspect(1); ok {
+		t.Fatal("expected absent key not to be inspectable")
+	}
+	c.SetWithTTL(1, 1, time.Second)
+	s := c.shards[c.shardIndex(1)]
+	want := []float64{0, 1, 3, math.Inf(1)}
+	for b := 0; b < 4; b++ {
+		info, ok := c.Inspect(1)
+		if !ok || info.Bucket != b || info.Accesses != want[b] || info.Shard != c.shardIndex(1) {
+			t.Fatalf("bucket %d: unexpected %+v, %v", b, info, ok)
+		}
+		if !info.Expires.Equal(deadline) {
+			t.Fatalf("unexpected deadline %v", info.Expires)
+		}
+		if b < 3 {
+			s.promote(int8(b), 1)
+		}
+	}
+	if st := c.Stats(); st.Hits != 0 || st.Misses != 0 {
+		t.Fatal("expected Inspect not to count as a lookup")
+	}
+
+	hist := c.Histogram()
+	if len(hist) != 4 {
+		t.Fatalf("expected a histogram per shard, got %d", len(hist))
+	}
+	for i, h := range hist {
+		if !reflect.DeepEqual(h, c.ShardStats()[i].Buckets) {
+			t.Fatalf("shard %d: histogram %v does not match stats", i, h)
+		}
+	}
+}
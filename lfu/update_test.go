@@ -0,0 +1,50 @@
+package lfu
+
+import (
+	"testing"
+
+	"github.com/tysonmote/cache/cachetest"
+)
+
+func TestUpdateKeepsBucket(t *testing.T) {
+	c := NewWithOptions[int, int](10, WithPromotionCurve([]float64{0, 0, 0}))
+	if c.Update(1, 1) {
+		t.Fatal("expected Update of an absent key to fail")
+	}
+	if c.Len() != 0 {
+		t.Fatal("expected Update not to add an absent key")
+	}
+	c.Set(1, 1)
+	s := c.shards[0]
+	s.promote(0, 1)
+	if !c.Update(1, 2) {
+		t.Fatal("expected Update of a present key to succeed")
+	}
+	if v, _ := c.Peek(1); v != 2 || s.index[1] != 1 {
+		t.Fatalf("expected value 2 in bucket 1, got %d in bucket %d", v, s.index[1])
+	}
+}
+
+func TestPreserveFrequency(t *testing.T) {
+	c := NewWithOptions[int, int](10, WithPreserveFrequency())
+	c.Set(1, 1)
+	s := c.shards[0]
+	s.promote(0, 1)
+	c.Set(1, 2)
+	if v, _ := c.Peek(1); v != 2 || s.index[1] != 1 {
+		t.Fatalf("expected value 2 in bucket 1, got %d in bucket %d", v, s.index[1])
+	}
+	if st := c.Stats(); st.Sets != 2 || st.Overwrites != 1 {
+		t.Fatalf("expected 2 sets and 1 overwrite, got %d and %d", st.Sets, st.Overwrites)
+	}
+}
+
+func TestPreserveFrequencyRefreshHitRatio(t *testing.T) {
+	const size = 1000
+	reset := cachetest.RefreshHitRatio(NewWithOptions[int, int](size, WithSeed(1)), size)
+	preserve := cachetest.RefreshHitRatio(NewWithOptions[int, int](size, WithSeed(1), WithPreserveFrequency()), size)
+	t.Logf("hot hit ratio: reset %.3f, preserve %.3f", reset, preserve)
+	if preserve < reset+0.1 {
+		t.Fatalf("expected preserving frequency to raise the hot hit ratio well above %.3f, got %.3f", reset, preserve)
+	}
+}
@@ -0,0 +1,251 @@
+package s3fifo
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/tysonmote/cache/internal/list"
+)
+
+// maxFreq is the highest access count an entry records.
+const maxFreq = 3
+
+// Cache is a thread-safe, fixed-size, in-memory cache with the S3-FIFO
+// eviction policy described in "FIFO queues are all you need for cache
+// eviction" (Yang et al., SOSP 2023): https://dl.acm.org/doi/10.1145/3600006.3613147
+//
+// New keys enter a small FIFO queue holding about a tenth of the capacity.
+// When an entry reaches the tail of the small queue, it moves to the main FIFO
+// queue if it was read more than once and is evicted otherwise, which removes
+// one-hit wonders quickly. Entries at the tail of the main queue that were
+// read since they last passed through it are reinserted at the head with a
+// decremented count, as in CLOCK. The keys of entries evicted from the small
+// queue are remembered in a ghost queue, and a key found there is added
+// directly to the main queue.
+//
+// A hit only increments the entry's counter atomically, so Get takes a read
+// lock and concurrent hits do not contend.
+type Cache[K comparable, V any] struct {
+	mu      sync.RWMutex
+	entries map[K]*entry[K, V]
+
+	small, main list.List[entry[K, V], *entry[K, V]]
+	ghost       ghost[K]
+
+	size, smallSize int
+}
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+	freq  atomic.Uint32
+	main  bool
+
+	list.Link[entry[K, V]]
+}
+
+// New returns a new Cache ready for use with a maximum capacity of size
+// items. A size of 0 disables caching: Set does not retain entries and Get
+// always misses.
+func New[K comparable, V any](size int) *Cache[K, V] {
+	if size < 0 {
+		panic("s3fifo: size must not be negative")
+	}
+	smallSize := size / 10
+	if smallSize < 1 {
+		smallSize = 1
+	}
+	return &Cache[K, V]{
+		entries:   map[K]*entry[K, V]{},
+		ghost:     newGhost[K](size),
+		size:      size,
+		smallSize: smallSize,
+	}
+}
+
+// Get returns a value from the cache if it exists. If the value does not
+// exist, ok is false.
+func (c *Cache[K, V]) Get(key K) (v V, ok bool) {
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	if !ok {
+		c.mu.RUnlock()
+		return v, false
+	}
+	for {
+		f := e.freq.Load()
+		if f >= maxFreq || e.freq.CompareAndSwap(f, f+1) {
+			break
+		}
+	}
+	v = e.value
+	c.mu.RUnlock()
+	return v, true
+}
+
+// Peek returns a value from the cache if it exists, without counting an
+// access. If the value does not exist, ok is false.
+func (c *Cache[K, V]) Peek(key K) (v V, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if e, ok := c.entries[key]; ok {
+		return e.value, true
+	}
+	return v, false
+}
+
+// Set adds or updates a value in the cache. Updating an existing key keeps
+// its position and access count. If the cache is full and the key is new, an
+// entry is evicted to make room.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.value = value
+		return
+	}
+	if c.size == 0 {
+		return
+	}
+	// Check the ghost queue before evicting, since an eviction adds a key to
+	// it and may push this one out.
+	ghosted := c.ghost.remove(key)
+	for len(c.entries) >= c.size {
+		c.evict()
+	}
+	e := &entry[K, V]{key: key, value: value}
+	c.entries[key] = e
+	if ghosted {
+		e.main = true
+		c.main.Push(e)
+	} else {
+		c.small.Push(e)
+	}
+}
+
+// Remove deletes a key from the cache. It returns true if the key was present.
+func (c *Cache[K, V]) Remove(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+	delete(c.entries, key)
+	if e.main {
+		c.main.Remove(e)
+	} else {
+		c.small.Remove(e)
+	}
+	return true
+}
+
+// Clear removes all entries from the cache and forgets the ghost queue.
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[K]*entry[K, V]{}
+	var empty list.List[entry[K, V], *entry[K, V]]
+	c.small, c.main = empty, empty
+	c.ghost = newGhost[K](c.size)
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache[K, V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// evict removes one entry, from the small queue if it holds at least its
+// share of the capacity and from the main queue otherwise.
+func (c *Cache[K, V]) evict() {
+	if c.small.Len() >= c.smallSize || c.main.Len() == 0 {
+		if c.evictSmall() {
+			return
+		}
+	}
+	c.evictMain()
+}
+
+// evictSmall evicts the first entry at the tail of the small queue that was
+// read at most once, moving the others to the main queue. It returns false if
+// it emptied the small queue without evicting anything.
+func (c *Cache[K, V]) evictSmall() bool {
+	for c.small.Len() > 0 {
+		e := c.small.Pop()
+		if e.freq.Load() > 1 {
+			e.freq.Store(0)
+			e.main = true
+			c.main.Push(e)
+			continue
+		}
+		delete(c.entries, e.key)
+		c.ghost.add(e.key)
+		return true
+	}
+	return false
+}
+
+// evictMain evicts the first entry at the tail of the main queue that was not
+// read since it was last reinserted, reinserting the others.
+func (c *Cache[K, V]) evictMain() {
+	for c.main.Len() > 0 {
+		e := c.main.Pop()
+		if f := e.freq.Load(); f > 0 {
+			e.freq.Store(f - 1)
+			c.main.Push(e)
+			continue
+		}
+		delete(c.entries, e.key)
+		return
+	}
+}
+
+// ghost is a bounded FIFO set of keys recently evicted from the small queue.
+// keys is a ring buffer in insertion order, and seq maps each member to the
+// sequence number of its latest insertion, so a stale ring slot left by a key
+// that was removed and added again does not remove the newer insertion.
+type ghost[K comparable] struct {
+	keys []ghostKey[K]
+	head int
+	seq  map[K]uint64
+	next uint64
+}
+
+type ghostKey[K comparable] struct {
+	key K
+	seq uint64
+}
+
+func newGhost[K comparable](size int) ghost[K] {
+	return ghost[K]{keys: make([]ghostKey[K], 0, size), seq: map[K]uint64{}}
+}
+
+func (g *ghost[K]) add(key K) {
+	if cap(g.keys) == 0 {
+		return
+	}
+	g.next++
+	k := ghostKey[K]{key, g.next}
+	if len(g.keys) < cap(g.keys) {
+		g.keys = append(g.keys, k)
+	} else {
+		old := g.keys[g.head]
+		if g.seq[old.key] == old.seq {
+			delete(g.seq, old.key)
+		}
+		g.keys[g.head] = k
+		g.head = (g.head + 1) % len(g.keys)
+	}
+	g.seq[key] = g.next
+}
+
+// remove reports whether key is in the ghost queue and removes it.
+func (g *ghost[K]) remove(key K) bool {
+	if _, ok := g.seq[key]; !ok {
+		return false
+	}
+	delete(g.seq, key)
+	return true
+}
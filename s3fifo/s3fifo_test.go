@@ -0,0 +1,95 @@
+package s3fifo
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tysonmote/cache/cachetest"
+)
+
+func TestCache(t *testing.T) {
+	err := cachetest.TestCache(func(size int) cachetest.Cache[int, int] {
+		return New[int, int](size)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRandomOps(t *testing.T) {
+	err := cachetest.TestRandomOps(New[int, int], 64, func(c *Cache[int, int]) error {
+		if n, queued := c.Len(), c.small.Len()+c.main.Len(); n != queued {
+			return fmt.Errorf("expected every entry to be queued, got %d entries and %d queued", n, queued)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanResistance(t *testing.T) {
+	c := New[int, int](100)
+	// Hot keys are read twice, so they move to the main queue.
+	for k := 0; k < 50; k++ {
+		c.Set(k, k)
+		c.Get(k)
+		c.Get(k)
+	}
+	// A scan of one-off keys churns only the small queue.
+	for k := 1000; k < 10_000; k++ {
+		c.Set(k, k)
+		for h := 0; h < 50; h += 7 {
+			c.Get(h)
+		}
+	}
+	for k := 0; k < 50; k++ {
+		if _, ok := c.Peek(k); !ok {
+			t.Fatalf("expected hot key %d to survive the scan", k)
+		}
+	}
+	if c.Len() != 100 {
+		t.Fatalf("expected a full cache, got %d entries", c.Len())
+	}
+}
+
+func TestGhostAdmitsToMain(t *testing.T) {
+	c := New[int, int](10)
+	for k := 0; k < 11; k++ {
+		c.Set(k, k)
+	}
+	// Key 0 was evicted from the small queue into the ghost queue, so adding
+	// it again puts it straight into the main queue.
+	if _, ok := c.Peek(0); ok {
+		t.Fatal("expected key 0 to be evicted")
+	}
+	c.Set(0, 0)
+	if e := c.entries[0]; e == nil || !e.main {
+		t.Fatal("expected key 0 to be readmitted to the main queue")
+	}
+	if !c.Remove(0) || c.main.Len() != 0 {
+		t.Fatal("expected Remove to unlink the entry from the main queue")
+	}
+	c.Clear()
+	if c.Len() != 0 || c.small.Len() != 0 || len(c.ghost.seq) != 0 {
+		t.Fatal("expected Clear to empty every queue")
+	}
+}
+
+func TestOldestGhostAdmitsToMain(t *testing.T) {
+	c := New[int, int](10)
+	// Keys 0 through 9 are evicted from the small queue in turn, filling the
+	// ghost queue with key 0 as its oldest member.
+	for k := 0; k < 20; k++ {
+		c.Set(k, k)
+	}
+	// Making room for key 0 adds key 10 to the full ghost queue, which must
+	// not cost key 0 its readmission to the main queue.
+	c.Set(0, 0)
+	if e := c.entries[0]; e == nil || !e.main {
+		t.Fatal("expected key 0 to be readmitted to the main queue")
+	}
+	if _, ok := c.ghost.seq[0]; ok {
+		t.Fatal("expected key 0 to leave the ghost queue")
+	}
+}
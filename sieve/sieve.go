@@ -0,0 +1,211 @@
+package sieve
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/tysonmote/cache/internal/keyhash"
+	"github.com/tysonmote/cache/internal/split"
+)
+
+// Cache is a thread-safe, fixed-size, in-memory cache with the SIEVE eviction
+// policy described in "SIEVE is Simpler than LRU: an Efficient Turn-Key
+// Eviction Algorithm for Web Caches" (Zhang et al., NSDI 2024):
+// https://www.usenix.org/conference/nsdi24/presentation/zhang-yazhuo
+//
+// Entries are kept in a single FIFO queue, newest first, each with a visited
+// bit that Get sets. To evict, a hand moves from the oldest entry toward the
+// newest, clearing visited bits, and evicts the first entry whose bit is
+// already clear; the hand stays where it stopped for the next eviction.
+// Unlike LRU, a hit never moves an entry, so Get takes only a read lock and
+// concurrent hits do not contend.
+//
+// A Cache is implemented as one or more shards, each with its own lock and
+// queue, as in lfu: New uses a single shard, and NewSharded splits the
+// capacity across up to numShards shards, using fewer when size is small so
+// each holds at least 64 keys on average. Eviction is local to each shard.
+type Cache[K comparable, V any] struct {
+	shards []*shard[K, V]
+	keys   *keyhash.Func[K]
+}
+
+type shard[K comparable, V any] struct {
+	mu      sync.RWMutex
+	cap     int
+	entries map[K]*entry[K, V]
+
+	// head is the newest entry and tail the oldest. hand is the next entry
+	// eviction examines, or nil to start from tail.
+	head, tail, hand *entry[K, V]
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	visited atomic.Bool
+
+	// prev is the next newer entry and next the next older one.
+	prev, next *entry[K, V]
+}
+
+// New returns a new Cache ready for use with a maximum capacity of size
+// items. A size of 0 disables caching: Set does not retain entries and Get
+// always misses.
+func New[K comparable, V any](size int) *Cache[K, V] {
+	return NewSharded[K, V](size, 1)
+}
+
+// NewSharded returns a cache with up to numShards shards, each with its own
+// lock, so concurrent operations on different keys can proceed in parallel.
+// The capacity is split as in lfu's WithShards, so the cache may hold
+// somewhat more than size entries in total. NewSharded panics if numShards is
+// less than 1.
+func NewSharded[K comparable, V any](size, numShards int) *Cache[K, V] {
+	if size < 0 {
+		panic("sieve: size must not be negative")
+	}
+	if numShards < 1 {
+		panic("sieve: numShards must be at least 1")
+	}
+	n := split.Shards(size, numShards)
+	c := &Cache[K, V]{
+		shards: make([]*shard[K, V], n),
+		keys:   keyhash.New[K](),
+	}
+	cap := split.Capacity(size, n, false)
+	for i := range c.shards {
+		c.shards[i] = &shard[K, V]{cap: cap, entries: map[K]*entry[K, V]{}}
+	}
+	return c
+}
+
+func (c *Cache[K, V]) shard(key K) *shard[K, V] {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	return c.shards[c.keys.Sum64(key)%uint64(len(c.shards))]
+}
+
+// Get returns a value from the cache if it exists. If the value does not
+// exist, ok is false.
+func (c *Cache[K, V]) Get(key K) (v V, ok bool) {
+	s := c.shard(key)
+	s.mu.RLock()
+	e, ok := s.entries[key]
+	if ok {
+		if !e.visited.Load() {
+			e.visited.Store(true)
+		}
+		v = e.value
+	}
+	s.mu.RUnlock()
+	return v, ok
+}
+
+// Peek returns a value from the cache if it exists, without marking it
+// visited. If the value does not exist, ok is false.
+func (c *Cache[K, V]) Peek(key K) (v V, ok bool) {
+	s := c.shard(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if e, ok := s.entries[key]; ok {
+		return e.value, true
+	}
+	return v, false
+}
+
+// Set adds or updates a value in the cache. Updating an existing key keeps
+// its position and visited bit. If the cache is full and the key is new, an
+// entry is evicted to make room.
+func (c *Cache[K, V]) Set(key K, value V) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok {
+		e.value = value
+		return
+	}
+	if s.cap == 0 {
+		return
+	}
+	for len(s.entries) >= s.cap {
+		s.evict()
+	}
+	e := &entry[K, V]{key: key, value: value, next: s.head}
+	if s.head != nil {
+		s.head.prev = e
+	} else {
+		s.tail = e
+	}
+	s.head = e
+	s.entries[key] = e
+}
+
+// Remove deletes a key from the cache. It returns true if the key was present.
+func (c *Cache[K, V]) Remove(key K) bool {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if ok {
+		s.remove(e)
+	}
+	return ok
+}
+
+// Clear removes all entries from the cache.
+func (c *Cache[K, V]) Clear() {
+	for _, s := range c.shards {
+		s.mu.Lock()
+		s.entries = map[K]*entry[K, V]{}
+		s.head, s.tail, s.hand = nil, nil, nil
+		s.mu.Unlock()
+	}
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache[K, V]) Len() int {
+	n := 0
+	for _, s := range c.shards {
+		s.mu.RLock()
+		n += len(s.entries)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// evict moves the hand toward newer entries, clearing visited bits, and
+// removes the first entry that was not visited.
+func (s *shard[K, V]) evict() {
+	e := s.hand
+	if e == nil {
+		e = s.tail
+	}
+	for e.visited.Load() {
+		e.visited.Store(false)
+		if e = e.prev; e == nil {
+			e = s.tail
+		}
+	}
+	// Removing the hand's entry leaves the hand at the next newer one.
+	s.hand = e
+	s.remove(e)
+}
+
+func (s *shard[K, V]) remove(e *entry[K, V]) {
+	if s.hand == e {
+		s.hand = e.prev
+	}
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		s.head = e.next
+	}
+	if e.next != nil {
+		e.next.prev = e.prev
+	} else {
+		s.tail = e.prev
+	}
+	e.prev, e.next = nil, nil
+	delete(s.entries, e.key)
+}
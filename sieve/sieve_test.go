@@ -0,0 +1,88 @@
+package sieve
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tysonmote/cache/cachetest"
+)
+
+func TestCache(t *testing.T) {
+	err := cachetest.TestCache(func(size int) cachetest.Cache[int, int] {
+		return New[int, int](size)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShardedCache(t *testing.T) {
+	err := cachetest.TestCache(func(size int) cachetest.Cache[int, int] {
+		return NewSharded[int, int](size, 32)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandKeepsVisitedEntries(t *testing.T) {
+	c := New[int, int](4)
+	for k := 0; k < 4; k++ {
+		c.Set(k, k)
+	}
+	c.Get(0)
+	c.Get(2)
+	// The hand clears 0's bit, evicts 1, and stops at 2.
+	c.Set(4, 4)
+	if _, ok := c.Peek(1); ok {
+		t.Fatal("expected the oldest unvisited key to be evicted")
+	}
+	// From 2 it clears 2's bit and evicts 3, leaving the hand at 4.
+	c.Set(5, 5)
+	if _, ok := c.Peek(3); ok {
+		t.Fatal("expected the hand to continue from where it stopped")
+	}
+	for _, k := range []int{0, 2, 4, 5} {
+		if _, ok := c.Peek(k); !ok {
+			t.Fatalf("expected key %d to remain", k)
+		}
+	}
+}
+
+func TestShardedCapacity(t *testing.T) {
+	c := NewSharded[int, int](1000, 16)
+	if len(c.shards) != 15 {
+		t.Fatalf("expected 15 shards of at least 64 keys, got %d", len(c.shards))
+	}
+	for k := 0; k < 10_000; k++ {
+		c.Set(k, k)
+	}
+	for i, s := range c.shards {
+		if len(s.entries) != s.cap {
+			t.Fatalf("expected shard %d to be full at %d entries, got %d", i, s.cap, len(s.entries))
+		}
+	}
+	c.Clear()
+	if c.Len() != 0 {
+		t.Fatal("expected Clear to empty the cache")
+	}
+}
+
+func TestRandomOps(t *testing.T) {
+	newSharded := func(size int) *Cache[int, int] { return NewSharded[int, int](size, 16) }
+	err := cachetest.TestRandomOps(newSharded, 64, func(c *Cache[int, int]) error {
+		for i, s := range c.shards {
+			queued := 0
+			for e := s.head; e != nil; e = e.next {
+				queued++
+			}
+			if n := len(s.entries); n != queued {
+				return fmt.Errorf("shard %d: expected every entry to be queued, got %d entries and %d queued", i, n, queued)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,355 @@
+package wtinylfu
+
+import (
+	"sync"
+
+	"github.com/tysonmote/cache/internal/keyhash"
+	"github.com/tysonmote/cache/internal/list"
+	"github.com/tysonmote/cache/internal/sketch"
+)
+
+const (
+	// windowPercent is the initial share of the capacity given to the window.
+	windowPercent = 1
+
+	// protectedPercent is the share of the main region given to its protected
+	// segment.
+	protectedPercent = 80
+
+	// sampleMultiplier sets the number of Get calls, as a multiple of the
+	// capacity, over which the hill climber measures the hit ratio.
+	sampleMultiplier = 10
+
+	// stepPercent is the share of the capacity by which the hill climber first
+	// moves the window, and again after a restart.
+	stepPercent = 6.25
+
+	// stepDecay shrinks the step after each adjustment so the window settles.
+	stepDecay = 0.98
+
+	// restartThreshold is the change in hit ratio between samples that makes
+	// the hill climber restart with a full step, as after a workload shift.
+	restartThreshold = 0.05
+)
+
+type region uint8
+
+const (
+	window region = iota
+	probation
+	protected
+)
+
+// Cache is a thread-safe, fixed-size, in-memory cache with the W-TinyLFU
+// eviction policy described in "TinyLFU: A Highly Efficient Cache Admission
+// Policy" (Einziger et al., ACM Transactions on Storage, 2017):
+// https://dl.acm.org/doi/10.1145/3149371
+//
+// New keys enter a small LRU window holding about 1% of the capacity. An entry
+// pushed out of the window becomes a candidate for the main region, a
+// segmented LRU made of a probation segment and a protected segment holding
+// 80% of the main region. The candidate is admitted only if a TinyLFU
+// frequency sketch estimates that it is accessed at least as often as the
+// probation segment's LRU entry, which is evicted in its place; otherwise the
+// candidate is evicted. A hit in the probation segment promotes the entry to
+// the protected segment, whose LRU entry is demoted back to probation when it
+// is full. The window lets bursts of new keys build up frequency before they
+// must compete for admission, and the sketch's counters are halved
+// periodically so old popularity fades.
+//
+// Frequency is recorded by Get, for hits and misses alike, so a key that is
+// only ever Set competes for admission with an estimate of zero.
+//
+// A hit reorders the entry's LRU list, so every operation takes an exclusive
+// lock.
+type Cache[K comparable, V any] struct {
+	mu      sync.Mutex
+	entries map[K]*entry[K, V]
+	keys    *keyhash.Func[K]
+	sketch  *sketch.TinyLFU
+
+	window, probation, protected list.List[entry[K, V], *entry[K, V]]
+
+	size, windowSize, protectedSize int
+
+	// climber is nil unless WithHillClimbing is set.
+	climber *climber
+}
+
+type entry[K comparable, V any] struct {
+	key    K
+	value  V
+	hash   uint64
+	region region
+
+	list.Link[entry[K, V]]
+}
+
+// Option configures a Cache at construction.
+type Option func(*options)
+
+type options struct {
+	hillClimbing bool
+}
+
+// WithHillClimbing makes the cache resize its window as the workload changes,
+// as Caffeine does. After every 10×size calls to Get, the cache compares the
+// hit ratio with that of the previous sample and moves capacity between the
+// window and the main region, continuing in the same direction if the hit
+// ratio improved and reversing otherwise. The step starts at 6.25% of the
+// capacity and decays with each adjustment, restarting when the hit ratio
+// changes by more than 5 percentage points. A larger window favors recency,
+// and a smaller one favors frequency.
+func WithHillClimbing() Option {
+	return func(o *options) {
+		o.hillClimbing = true
+	}
+}
+
+// New returns a new Cache ready for use with a maximum capacity of size
+// items. A size of 0 disables caching: Set does not retain entries and Get
+// always misses.
+func New[K comparable, V any](size int, opts ...Option) *Cache[K, V] {
+	if size < 0 {
+		panic("wtinylfu: size must not be negative")
+	}
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	c := &Cache[K, V]{
+		entries: map[K]*entry[K, V]{},
+		keys:    keyhash.New[K](),
+		sketch:  sketch.NewTinyLFU(size),
+		size:    size,
+	}
+	windowSize := size * windowPercent / 100
+	if windowSize < 1 {
+		windowSize = 1
+	}
+	c.resizeWindow(windowSize)
+	if o.hillClimbing && size > 1 {
+		c.climber = newClimber(size)
+	}
+	return c
+}
+
+// Get returns a value from the cache if it exists. If the value does not
+// exist, ok is false.
+func (c *Cache[K, V]) Get(key K) (v V, ok bool) {
+	h := c.keys.Sum64(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sketch.Record(h)
+	e, ok := c.entries[key]
+	if c.climber != nil {
+		if n := c.climber.record(ok); n != 0 {
+			c.resizeWindow(c.windowSize + n)
+		}
+	}
+	if !ok {
+		return v, false
+	}
+	switch e.region {
+	case window:
+		c.window.Remove(e)
+		c.window.Push(e)
+	case probation:
+		c.probation.Remove(e)
+		e.region = protected
+		c.protected.Push(e)
+		c.demote()
+	case protected:
+		c.protected.Remove(e)
+		c.protected.Push(e)
+	}
+	return e.value, true
+}
+
+// Peek returns a value from the cache if it exists, without recording an
+// access or changing its recency. If the value does not exist, ok is false.
+func (c *Cache[K, V]) Peek(key K) (v V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		return e.value, true
+	}
+	return v, false
+}
+
+// Set adds or updates a value in the cache. Updating an existing key keeps
+// its position. A new key enters the window, and if the cache is full, an
+// entry is evicted to make room: the window's LRU entry or the probation
+// segment's, depending on their estimated frequencies.
+func (c *Cache[K, V]) Set(key K, value V) {
+	h := c.keys.Sum64(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.value = value
+		return
+	}
+	if c.size == 0 {
+		return
+	}
+	e := &entry[K, V]{key: key, value: value, hash: h}
+	c.entries[key] = e
+	c.window.Push(e)
+	c.evict()
+}
+
+// Remove deletes a key from the cache. It returns true if the key was present.
+func (c *Cache[K, V]) Remove(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if ok {
+		c.remove(e)
+	}
+	return ok
+}
+
+// Clear removes all entries from the cache and forgets the frequency sketch.
+// A window resized by WithHillClimbing keeps its size.
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[K]*entry[K, V]{}
+	var empty list.List[entry[K, V], *entry[K, V]]
+	c.window, c.probation, c.protected = empty, empty, empty
+	c.sketch = sketch.NewTinyLFU(c.size)
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// evict moves entries that overflow the window to the probation segment as
+// candidates, then evicts entries until the cache is within its capacity.
+// Each candidate, oldest first, competes with the probation segment's LRU
+// entry, and the one with the lower estimated frequency is evicted; ties
+// favor the candidate.
+func (c *Cache[K, V]) evict() {
+	var candidate *entry[K, V]
+	candidates := 0
+	for c.window.Len() > c.windowSize {
+		e := c.window.Pop()
+		e.region = probation
+		c.probation.Push(e)
+		if candidate == nil {
+			candidate = e
+		}
+		candidates++
+	}
+	for len(c.entries) > c.size {
+		victim := c.probation.Back()
+		if victim == candidate {
+			// Every entry in probation is a candidate, so the victim comes
+			// from the protected segment.
+			victim = c.protected.Back()
+		}
+		if candidates == 0 {
+			if victim == nil {
+				victim = c.window.Back()
+			}
+			c.remove(victim)
+			continue
+		}
+		next := candidate.Prev()
+		if victim == nil || !c.sketch.Admit(candidate.hash, victim.hash) {
+			c.remove(candidate)
+		} else {
+			c.remove(victim)
+		}
+		candidate = next
+		candidates--
+	}
+}
+
+// demote moves entries that overflow the protected segment to the probation
+// segment.
+func (c *Cache[K, V]) demote() {
+	for c.protected.Len() > c.protectedSize {
+		e := c.protected.Pop()
+		e.region = probation
+		c.probation.Push(e)
+	}
+}
+
+// resizeWindow sets the window's capacity to n, clamped to [1, size], and
+// sizes the protected segment to its share of the rest. Entries that overflow
+// the protected segment are demoted at once; entries that overflow the window
+// move to the main region when the next new key is added.
+func (c *Cache[K, V]) resizeWindow(n int) {
+	if n > c.size {
+		n = c.size
+	}
+	if n < 1 {
+		n = 1
+	}
+	c.windowSize = n
+	c.protectedSize = (c.size - n) * protectedPercent / 100
+	c.demote()
+}
+
+func (c *Cache[K, V]) remove(e *entry[K, V]) {
+	switch e.region {
+	case window:
+		c.window.Remove(e)
+	case probation:
+		c.probation.Remove(e)
+	case protected:
+		c.protected.Remove(e)
+	}
+	delete(c.entries, e.key)
+}
+
+// climber adjusts the window's size by hill climbing on the hit ratio.
+type climber struct {
+	size         int
+	sample       int
+	hits, misses int
+	prevRatio    float64
+	step         float64
+}
+
+func newClimber(size int) *climber {
+	return &climber{
+		size:   size,
+		sample: sampleMultiplier * size,
+		step:   stepPercent / 100 * float64(size),
+	}
+}
+
+// record counts a hit or miss and, at the end of each sample, returns the
+// number of entries by which to grow the window, or shrink it if negative.
+func (h *climber) record(hit bool) int {
+	if hit {
+		h.hits++
+	} else {
+		h.misses++
+	}
+	if h.hits+h.misses < h.sample {
+		return 0
+	}
+	ratio := float64(h.hits) / float64(h.hits+h.misses)
+	delta := ratio - h.prevRatio
+	amount := h.step
+	if delta < 0 {
+		amount = -amount
+	}
+	if delta <= -restartThreshold || delta >= restartThreshold {
+		h.step = stepPercent / 100 * float64(h.size)
+		if amount < 0 {
+			h.step = -h.step
+		}
+	} else {
+		h.step = stepDecay * amount
+	}
+	h.prevRatio = ratio
+	h.hits, h.misses = 0, 0
+	return int(amount)
+}
@@ -0,0 +1,124 @@
+package wtinylfu
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tysonmote/cache/cachetest"
+)
+
+func TestCache(t *testing.T) {
+	err := cachetest.TestCache(func(size int) cachetest.Cache[int, int] {
+		return New[int, int](size)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHillClimbingCache(t *testing.T) {
+	err := cachetest.TestCache(func(size int) cachetest.Cache[int, int] {
+		return New[int, int](size, WithHillClimbing())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRandomOps(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithHillClimbing()}} {
+		create := func(size int) *Cache[int, int] { return New[int, int](size, opts...) }
+		err := cachetest.TestRandomOps(create, 64, func(c *Cache[int, int]) error {
+			if n, queued := c.Len(), c.window.Len()+c.probation.Len()+c.protected.Len(); n != queued {
+				return fmt.Errorf("expected every entry to be queued, got %d entries and %d queued", n, queued)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRegions(t *testing.T) {
+	c := New[int, int](1000)
+	if c.windowSize != 10 || c.protectedSize != 792 {
+		t.Fatalf("expected a window of 10 and a protected segment of 792, got %d and %d", c.windowSize, c.protectedSize)
+	}
+	for k := 0; k < 1000; k++ {
+		c.Get(k)
+		c.Set(k, k)
+	}
+	if c.window.Len() != 10 || c.probation.Len() != 990 {
+		t.Fatalf("expected 10 entries in the window and 990 in probation, got %d and %d", c.window.Len(), c.probation.Len())
+	}
+	// A hit in probation promotes to protected, which demotes its own LRU
+	// entries once it is full.
+	for k := 0; k < 990; k++ {
+		c.Get(k)
+	}
+	if c.protected.Len() != 792 || c.probation.Len() != 198 {
+		t.Fatalf("expected 792 protected and 198 probation entries, got %d and %d", c.protected.Len(), c.probation.Len())
+	}
+	if e := c.entries[0]; e.region != probation {
+		t.Fatal("expected the oldest promoted key to be demoted")
+	}
+	if !c.Remove(989) || c.protected.Len() != 791 || c.Len() != 999 {
+		t.Fatal("expected Remove to unlink the entry from the protected segment")
+	}
+	c.Clear()
+	if c.Len() != 0 || c.window.Len()+c.probation.Len()+c.protected.Len() != 0 {
+		t.Fatal("expected Clear to empty every region")
+	}
+}
+
+func TestScanResistance(t *testing.T) {
+	c := New[int, int](100)
+	// Hot keys are read often enough to be admitted and protected.
+	for i := 0; i < 10; i++ {
+		for k := 0; k < 50; k++ {
+			if _, ok := c.Get(k); !ok {
+				c.Set(k, k)
+			}
+		}
+	}
+	// A scan of one-off keys, interleaved with reads of the hot keys, churns
+	// only the window and probation.
+	for k := 1000; k < 10_000; k++ {
+		c.Get(k)
+		c.Set(k, k)
+		for h := k % 7; h < 50; h += 7 {
+			c.Get(h)
+		}
+	}
+	for k := 0; k < 50; k++ {
+		if _, ok := c.Peek(k); !ok {
+			t.Fatalf("expected hot key %d to survive the scan", k)
+		}
+	}
+	if c.Len() != 100 {
+		t.Fatalf("expected a full cache, got %d entries", c.Len())
+	}
+}
+
+func TestHillClimbing(t *testing.T) {
+	const size = 1000
+	c := New[int, int](size, WithHillClimbing())
+	// Keys are read in a loop a little larger than the main region, so only a
+	// larger window, which is pure LRU, can serve the recent ones.
+	for i := 0; i < 100*size; i++ {
+		k := i % (size + size/2)
+		if _, ok := c.Get(k); !ok {
+			c.Set(k, k)
+		}
+	}
+	if c.windowSize == 10 {
+		t.Fatal("expected hill climbing to resize the window")
+	}
+	if c.windowSize < 1 || c.windowSize > size {
+		t.Fatalf("expected the window within [1, %d], got %d", size, c.windowSize)
+	}
+	if c.Len() > size || c.window.Len()+c.probation.Len()+c.protected.Len() != c.Len() {
+		t.Fatalf("expected at most %d entries, all queued, got %d", size, c.Len())
+	}
+}